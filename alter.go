@@ -0,0 +1,543 @@
+package remodel
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/juju/errors"
+	"github.com/xwb1989/sqlparser"
+)
+
+var (
+	alterTableRegexp  = regexp.MustCompile("(?is)^alter\\s+(?:ignore\\s+)?table\\s+([`\\w.]+)\\s+(.*)$")
+	renameTableRegexp = regexp.MustCompile(`(?is)^rename\s+tables?\s+(.*)$`)
+	dropTableRegexp   = regexp.MustCompile(`(?is)^drop\s+(?:temporary\s+)?tables?\s+(if\s+exists\s+)?(.*?)(?:\s+(?:restrict|cascade))?$`)
+
+	renamePairRegexp = regexp.MustCompile("(?is)^([`\\w.]+)\\s+to\\s+([`\\w.]+)$")
+	positionRegexp   = regexp.MustCompile("(?is)\\s+(first|after\\s+([`\\w]+))$")
+
+	addColumnsRegexp   = regexp.MustCompile(`(?is)^add\s+(?:column\s+)?\((.*)\)$`)
+	addIndexRegexp     = regexp.MustCompile(`(?is)^add\s+((?:unique|primary|index|key)\b.*)$`)
+	addConstraintRegex = regexp.MustCompile("(?is)^add\\s+constraint(?:\\s+[`\\w]+)?\\s+((?:unique|primary|foreign|check)\\b.*)$")
+	addColumnRegexp    = regexp.MustCompile(`(?is)^add\s+(?:column\s+)?(.*)$`)
+	dropPrimaryRegexp  = regexp.MustCompile(`(?is)^drop\s+primary\s+key$`)
+	dropIndexRegexp    = regexp.MustCompile("(?is)^drop\\s+(?:index|key)\\s+([`\\w]+)$")
+	dropForeignRegexp  = regexp.MustCompile(`(?is)^drop\s+(?:foreign\s+key|constraint|check)\s+`)
+	dropColumnRegexp   = regexp.MustCompile("(?is)^drop\\s+(?:column\\s+)?([`\\w]+)$")
+	changeColumnRegexp = regexp.MustCompile("(?is)^change\\s+(?:column\\s+)?([`\\w]+)\\s+(.*)$")
+	modifyColumnRegexp = regexp.MustCompile(`(?is)^modify\s+(?:column\s+)?(.*)$`)
+	renameColumnRegexp = regexp.MustCompile("(?is)^rename\\s+column\\s+([`\\w]+)\\s+to\\s+([`\\w]+)$")
+	renameIndexRegexp  = regexp.MustCompile("(?is)^rename\\s+(?:index|key)\\s+([`\\w]+)\\s+to\\s+([`\\w]+)$")
+	renameToRegexp     = regexp.MustCompile("(?is)^rename\\s+(?:(?:to|as)\\s+)?([`\\w.]+)$")
+	setDefaultRegexp   = regexp.MustCompile("(?is)^alter\\s+(?:column\\s+)?([`\\w]+)\\s+set\\s+default\\s+(.*)$")
+	dropDefaultRegexp  = regexp.MustCompile("(?is)^alter\\s+(?:column\\s+)?([`\\w]+)\\s+drop\\s+default$")
+	commentSpecRegexp  = regexp.MustCompile(`(?is)^comment\s*=?\s*'((?:[^']|'')*)'$`)
+	unnamedIndexRegexp = regexp.MustCompile("(?is)^((?:unique\\s+)?(?:index|key)|unique)\\s*\\(\\s*([`\\w]+)")
+	tableOptionRegexp  = regexp.MustCompile(`(?is)^(engine|auto_increment|row_format|(default\s+)?(charset|character\s+set|collate))\b`)
+	convertToRegexp    = regexp.MustCompile(`(?is)^convert\s+to\s+(.*)$`)
+
+	// table options and specifications which do not change the model
+	ignoredSpecRegexp = regexp.MustCompile(`(?is)^(add\s+(fulltext|spatial|foreign|check)\b|algorithm\b|lock\b|force$)`)
+)
+
+// alter applies ALTER TABLE specifications to the table.
+// The table is left unchanged when any of them fails.
+func (s *Tables) alter(sql string) (string, error) {
+	m := alterTableRegexp.FindStringSubmatch(sql)
+	name := unquoteIdent(m[1])
+	idx := s.find(name)
+	if idx < 0 {
+		return name, errors.Errorf("table %s does not exist", name)
+	}
+
+	t := (*s)[idx].clone()
+	for _, spec := range splitSpecs(m[2]) {
+		if err := s.alterSpec(t, spec); err != nil {
+			return name, errors.Annotatef(err, "cannot apply %q", spec)
+		}
+	}
+	t.resolve()
+	if ds := t.validate(); len(ds) > 0 {
+		return t.Name, ds
+	}
+	(*s)[idx] = t
+	if t.Name != name {
+		s.renameRelationTable(name, t.Name)
+	}
+	return t.Name, nil
+}
+
+func (s *Tables) alterSpec(t *Table, spec string) error {
+	if m := addForeignKeyRegexp.FindStringSubmatch(spec); m != nil {
+		r := parseForeignKey(m[1])
+		if r == nil {
+			return errors.Errorf("cannot parse foreign key %q", m[1])
+		}
+		return errors.Trace(t.addRelation(r))
+	}
+	if m := dropForeignKeyRegexp.FindStringSubmatch(spec); m != nil {
+		err := t.dropRelation(unquoteIdent(m[2]))
+		if err != nil && strings.EqualFold(m[1], "constraint") {
+			// DROP CONSTRAINT may drop a CHECK constraint
+			return nil
+		}
+		return errors.Trace(err)
+	}
+	if m := addConstraintRegex.FindStringSubmatch(spec); m != nil {
+		spec = "add " + m[1]
+	}
+	switch {
+	case ignoredSpecRegexp.MatchString(spec):
+		return nil
+	case tableOptionRegexp.MatchString(spec):
+		t.setCharset(spec)
+		return nil
+	case convertToRegexp.MatchString(spec):
+		// text columns are converted to the default of the table
+		t.setCharset(convertToRegexp.FindStringSubmatch(spec)[1])
+		for _, c := range t.Columns {
+			c.Charset, c.Collation = "", ""
+		}
+		return nil
+	case addColumnsRegexp.MatchString(spec):
+		m := addColumnsRegexp.FindStringSubmatch(spec)
+		for _, def := range splitSpecs(m[1]) {
+			c, err := parseColumnDefinition(def)
+			if err != nil {
+				return errors.Trace(err)
+			}
+			if err := t.addColumn(c, len(t.Columns)); err != nil {
+				return errors.Trace(err)
+			}
+		}
+		return nil
+	case addIndexRegexp.MatchString(spec):
+		m := addIndexRegexp.FindStringSubmatch(spec)
+		index, err := parseIndexDefinition(m[1])
+		if err != nil {
+			return errors.Trace(err)
+		}
+		return errors.Trace(t.addIndex(index))
+	case addColumnRegexp.MatchString(spec):
+		m := addColumnRegexp.FindStringSubmatch(spec)
+		def, pos, err := t.columnPosition(m[1], len(t.Columns))
+		if err != nil {
+			return errors.Trace(err)
+		}
+		c, err := parseColumnDefinition(def)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		return errors.Trace(t.addColumn(c, pos))
+	case dropPrimaryRegexp.MatchString(spec):
+		return errors.Trace(t.dropIndex("PRIMARY"))
+	case dropIndexRegexp.MatchString(spec):
+		m := dropIndexRegexp.FindStringSubmatch(spec)
+		return errors.Trace(t.dropIndex(unquoteIdent(m[1])))
+	case dropForeignRegexp.MatchString(spec):
+		return nil
+	case commentSpecRegexp.MatchString(spec):
+		m := commentSpecRegexp.FindStringSubmatch(spec)
+		t.Comment = strings.Replace(m[1], "''", "'", -1)
+		return nil
+	case dropColumnRegexp.MatchString(spec):
+		m := dropColumnRegexp.FindStringSubmatch(spec)
+		return errors.Trace(t.dropColumn(unquoteIdent(m[1])))
+	case changeColumnRegexp.MatchString(spec):
+		m := changeColumnRegexp.FindStringSubmatch(spec)
+		return errors.Trace(t.changeColumn(unquoteIdent(m[1]), m[2]))
+	case modifyColumnRegexp.MatchString(spec):
+		m := modifyColumnRegexp.FindStringSubmatch(spec)
+		def, _, err := t.columnPosition(m[1], 0)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		c, err := parseColumnDefinition(def)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		return errors.Trace(t.changeColumn(c.Name, m[1]))
+	case renameColumnRegexp.MatchString(spec):
+		m := renameColumnRegexp.FindStringSubmatch(spec)
+		return errors.Trace(t.renameColumn(unquoteIdent(m[1]), unquoteIdent(m[2])))
+	case renameIndexRegexp.MatchString(spec):
+		m := renameIndexRegexp.FindStringSubmatch(spec)
+		return errors.Trace(t.renameIndex(unquoteIdent(m[1]), unquoteIdent(m[2])))
+	case renameToRegexp.MatchString(spec):
+		m := renameToRegexp.FindStringSubmatch(spec)
+		newName := unquoteIdent(m[1])
+		if newName != t.Name && s.find(newName) >= 0 {
+			return errors.Errorf("table %s already exists", newName)
+		}
+		t.Name = newName
+		return nil
+	case setDefaultRegexp.MatchString(spec):
+		m := setDefaultRegexp.FindStringSubmatch(spec)
+		c := t.column(unquoteIdent(m[1]))
+		if c == nil {
+			return errors.Errorf("unknown column %s", m[1])
+		}
+		// parse the literal in the same way as CREATE TABLE
+		d, err := parseColumnDefinition("_remodel varchar(1) default " + m[2])
+		if err != nil {
+			return errors.Trace(err)
+		}
+		c.DefaultValue = d.DefaultValue
+		return nil
+	case dropDefaultRegexp.MatchString(spec):
+		m := dropDefaultRegexp.FindStringSubmatch(spec)
+		c := t.column(unquoteIdent(m[1]))
+		if c == nil {
+			return errors.Errorf("unknown column %s", m[1])
+		}
+		c.DefaultValue = ""
+		return nil
+	}
+	return errors.New("unsupported alter table specification")
+}
+
+// setCharset updates the charset and the collation of the table by the options which have them.
+func (t *Table) setCharset(options string) {
+	if charset := tableOption(options, tableCharsetRegexp); charset != "" {
+		t.Charset = charset
+	}
+	if collation := tableOption(options, tableCollationRegexp); collation != "" {
+		t.Collation = collation
+	}
+}
+
+// rename applies RENAME TABLE a TO b[, c TO d].
+func (s *Tables) rename(sql string) (string, error) {
+	m := renameTableRegexp.FindStringSubmatch(sql)
+	var name string
+	for _, pair := range splitSpecs(m[1]) {
+		pm := renamePairRegexp.FindStringSubmatch(pair)
+		if pm == nil {
+			return name, errors.Errorf("cannot parse %q", pair)
+		}
+		name = unquoteIdent(pm[1])
+		newName := unquoteIdent(pm[2])
+		idx := s.find(name)
+		if idx < 0 {
+			return name, errors.Errorf("table %s does not exist", name)
+		}
+		if s.find(newName) >= 0 {
+			return name, errors.Errorf("table %s already exists", newName)
+		}
+		t := (*s)[idx].clone()
+		t.Name = newName
+		t.resolve()
+		if ds := t.validate(); len(ds) > 0 {
+			return newName, ds
+		}
+		(*s)[idx] = t
+		s.renameRelationTable(name, newName)
+		name = newName
+	}
+	return name, nil
+}
+
+// drop applies DROP TABLE [IF EXISTS] a[, b].
+func (s *Tables) drop(sql string) (string, error) {
+	m := dropTableRegexp.FindStringSubmatch(sql)
+	ifExists := m[1] != ""
+	var name string
+	for _, n := range splitSpecs(m[2]) {
+		name = unquoteIdent(n)
+		idx := s.find(name)
+		if idx < 0 {
+			if ifExists {
+				continue
+			}
+			return name, errors.Errorf("table %s does not exist", name)
+		}
+		*s = append((*s)[:idx], (*s)[idx+1:]...)
+	}
+	return name, nil
+}
+
+func (t *Table) clone() *Table {
+	c := *t
+	c.Columns = make([]*Column, 0, len(t.Columns))
+	for _, col := range t.Columns {
+		cc := *col
+		cc.UniqueIndexKeys = append([]string{}, col.UniqueIndexKeys...)
+		cc.IndexKeys = append([]string{}, col.IndexKeys...)
+		c.Columns = append(c.Columns, &cc)
+	}
+	c.Indexes = make([]*Index, 0, len(t.Indexes))
+	for _, index := range t.Indexes {
+		ci := *index
+		ci.Columns = append([]string{}, index.Columns...)
+		c.Indexes = append(c.Indexes, &ci)
+	}
+	c.Relations = nil
+	for _, r := range t.Relations {
+		cr := *r
+		cr.Columns = append([]string{}, r.Columns...)
+		cr.RefColumns = append([]string{}, r.RefColumns...)
+		c.Relations = append(c.Relations, &cr)
+	}
+	return &c
+}
+
+func (t *Table) columnIndex(name string) int {
+	for idx, c := range t.Columns {
+		if strings.EqualFold(c.Name, name) {
+			return idx
+		}
+	}
+	return -1
+}
+
+func (t *Table) column(name string) *Column {
+	if idx := t.columnIndex(name); idx >= 0 {
+		return t.Columns[idx]
+	}
+	return nil
+}
+
+// columnPosition strips FIRST or AFTER from the column definition and returns the position to insert.
+func (t *Table) columnPosition(def string, defaultPos int) (string, int, error) {
+	m := positionRegexp.FindStringSubmatch(def)
+	if m == nil {
+		return def, defaultPos, nil
+	}
+	def = strings.TrimSuffix(def, m[0])
+	if strings.EqualFold(m[1], "first") {
+		return def, 0, nil
+	}
+	idx := t.columnIndex(unquoteIdent(m[2]))
+	if idx < 0 {
+		return def, 0, errors.Errorf("unknown column %s", m[2])
+	}
+	return def, idx + 1, nil
+}
+
+func (t *Table) addColumn(c *Column, pos int) error {
+	if t.columnIndex(c.Name) >= 0 {
+		return errors.Errorf("duplicate column %s", c.Name)
+	}
+	t.Columns = append(t.Columns, nil)
+	copy(t.Columns[pos+1:], t.Columns[pos:])
+	t.Columns[pos] = c
+	return nil
+}
+
+// dropColumn removes the column from the table and its indexes like MySQL does.
+func (t *Table) dropColumn(name string) error {
+	idx := t.columnIndex(name)
+	if idx < 0 {
+		return errors.Errorf("unknown column %s", name)
+	}
+	name = t.Columns[idx].Name
+	t.Columns = append(t.Columns[:idx], t.Columns[idx+1:]...)
+
+	indexes := t.Indexes[:0]
+	for _, index := range t.Indexes {
+		columns := index.Columns[:0]
+		for _, c := range index.Columns {
+			if c != name {
+				columns = append(columns, c)
+			}
+		}
+		index.Columns = columns
+		if len(columns) > 0 {
+			indexes = append(indexes, index)
+		}
+	}
+	t.Indexes = indexes
+	t.dropRelationColumn(name)
+	return nil
+}
+
+// changeColumn replaces the column with the definition, which may have a new name and position.
+func (t *Table) changeColumn(name, def string) error {
+	idx := t.columnIndex(name)
+	if idx < 0 {
+		return errors.Errorf("unknown column %s", name)
+	}
+	old := t.Columns[idx]
+	t.Columns = append(t.Columns[:idx], t.Columns[idx+1:]...)
+
+	def, pos, err := t.columnPosition(def, idx)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	c, err := parseColumnDefinition(def)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if err := t.addColumn(c, pos); err != nil {
+		return errors.Trace(err)
+	}
+	t.renameIndexColumn(old.Name, c.Name)
+	t.renameRelationColumn(old.Name, c.Name)
+	return nil
+}
+
+func (t *Table) renameColumn(name, newName string) error {
+	c := t.column(name)
+	if c == nil {
+		return errors.Errorf("unknown column %s", name)
+	}
+	if t.columnIndex(newName) >= 0 {
+		return errors.Errorf("duplicate column %s", newName)
+	}
+	oldName := c.Name
+	c.Name = newName
+	c.EntityType = c.entityType()
+	t.renameIndexColumn(oldName, newName)
+	t.renameRelationColumn(oldName, newName)
+	return nil
+}
+
+func (t *Table) renameIndexColumn(name, newName string) {
+	for _, index := range t.Indexes {
+		for i, c := range index.Columns {
+			if c == name {
+				index.Columns[i] = newName
+			}
+		}
+	}
+}
+
+func (t *Table) indexIndex(name string) int {
+	for idx, index := range t.Indexes {
+		if strings.EqualFold(index.Name, name) {
+			return idx
+		}
+	}
+	return -1
+}
+
+func (t *Table) addIndex(index *Index) error {
+	if index.IsPrimaryKey {
+		index.Name = "PRIMARY"
+	}
+	if t.indexIndex(index.Name) >= 0 {
+		if index.IsPrimaryKey {
+			return errors.New("multiple primary key defined")
+		}
+		return errors.Errorf("duplicate key name %s", index.Name)
+	}
+	for _, c := range index.Columns {
+		if t.columnIndex(c) < 0 {
+			return errors.Errorf("unknown column %s in index %s", c, index.Name)
+		}
+	}
+	t.Indexes = append(t.Indexes, index)
+	return nil
+}
+
+func (t *Table) dropIndex(name string) error {
+	idx := t.indexIndex(name)
+	if idx < 0 {
+		return errors.Errorf("unknown key %s", name)
+	}
+	t.Indexes = append(t.Indexes[:idx], t.Indexes[idx+1:]...)
+	return nil
+}
+
+func (t *Table) renameIndex(name, newName string) error {
+	idx := t.indexIndex(name)
+	if idx < 0 {
+		return errors.Errorf("unknown key %s", name)
+	}
+	if t.indexIndex(newName) >= 0 {
+		return errors.Errorf("duplicate key name %s", newName)
+	}
+	t.Indexes[idx].Name = newName
+	return nil
+}
+
+// parseColumnDefinition parses a column definition of ALTER TABLE with the CREATE TABLE parser.
+func parseColumnDefinition(def string) (*Column, error) {
+	spec, generated, err := parseTableSpec(def)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if len(spec.Columns) != 1 {
+		return nil, errors.Errorf("cannot parse column definition %q", def)
+	}
+	c, err := newColumn(spec.Columns[0])
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	c.IsGenerated = len(generated) > 0
+	return c, nil
+}
+
+// parseIndexDefinition parses an index definition of ALTER TABLE with the CREATE TABLE parser.
+// An index without name is named after its first column like MySQL does.
+func parseIndexDefinition(def string) (*Index, error) {
+	if m := unnamedIndexRegexp.FindStringSubmatch(def); m != nil {
+		def = fmt.Sprintf("%s %s %s", m[1], m[2], def[len(m[1]):])
+	}
+	spec, _, err := parseTableSpec("_remodel int, " + def)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if len(spec.Indexes) != 1 {
+		return nil, errors.Errorf("cannot parse index definition %q", def)
+	}
+	return newIndex(spec.Indexes[0]), nil
+}
+
+// parseTableSpec parses the definitions of CREATE TABLE and returns the names of the generated columns as well.
+func parseTableSpec(defs string) (*sqlparser.TableSpec, map[string]struct{}, error) {
+	normalized, generated := normalizeDump(fmt.Sprintf("CREATE TABLE _remodel (%s)", defs))
+	stmt, err := sqlparser.ParseStrictDDL(normalized)
+	if err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	ddl, ok := stmt.(*sqlparser.DDL)
+	if !ok || ddl.TableSpec == nil {
+		return nil, nil, errors.Errorf("cannot parse %q", defs)
+	}
+	return ddl.TableSpec, generated, nil
+}
+
+// splitSpecs splits s by commas outside of parentheses and quotes.
+func splitSpecs(s string) []string {
+	var (
+		specs []string
+		depth int
+		quote rune
+		start int
+	)
+	runes := []rune(s)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case quote != 0:
+			if r == '\\' && quote != '`' {
+				i++
+			} else if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"' || r == '`':
+			quote = r
+		case r == '(':
+			depth++
+		case r == ')':
+			depth--
+		case r == ',' && depth == 0:
+			specs = append(specs, strings.TrimSpace(string(runes[start:i])))
+			start = i + 1
+		}
+	}
+	if last := strings.TrimSpace(string(runes[start:])); last != "" {
+		specs = append(specs, last)
+	}
+	return specs
+}
+
+// unquoteIdent removes backquotes and the database qualifier from an identifier.
+func unquoteIdent(s string) string {
+	if idx := strings.LastIndex(s, "."); idx >= 0 {
+		s = s[idx+1:]
+	}
+	return strings.Trim(s, "`")
+}
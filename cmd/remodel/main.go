@@ -3,67 +3,195 @@ package main
 import (
 	"flag"
 	"fmt"
+	"io/ioutil"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/juju/errors"
 	"github.com/yuki-eto/remodel"
 )
 
 func main() {
-	if err := run(); err != nil {
-		log.Fatalf("err: %+v", err)
+	err := run()
+	if err == nil {
+		return
 	}
+	// every diagnostic is printed on its own line instead of the stack of the first one
+	if errs, ok := errors.Cause(err).(remodel.GenerateErrors); ok {
+		for _, e := range errs {
+			fmt.Fprintln(os.Stderr, e)
+		}
+		log.Fatalf("err: %d error(s) occurred", len(errs))
+	}
+	log.Fatalf("err: %+v", err)
 }
 
 func run() error {
 	var (
 		rootDir    string
+		configPath string
 		moduleName string
 		isProtoc   bool
 		isJSON     bool
+		isDryRun   bool
+		isWatch    bool
+		interval   time.Duration
+		tables     string
+		workers    int
+		isPrune    bool
 	)
 	flag.StringVar(&rootDir, "root", "", "root directory of project")
+	flag.StringVar(&configPath, "config", "", "config file path (default: (root)/remodel.yml)")
 	flag.StringVar(&moduleName, "module", "", "module name of project")
 	flag.BoolVar(&isProtoc, "proto", false, "necessary protocol buffers schema for entity")
 	flag.BoolVar(&isJSON, "json", false, "necessary json output")
+	flag.BoolVar(&isDryRun, "dry-run", false, "print unified diff against the files on disk instead of writing them")
+	flag.BoolVar(&isWatch, "watch", false, "watch schema/sql and schema/yaml and regenerate changed tables")
+	flag.StringVar(&tables, "tables", "", "comma separated glob patterns of tables to output (e.g. users,user_*)")
+	flag.IntVar(&workers, "workers", 0, "number of concurrent code generations (default: number of CPUs)")
+	flag.BoolVar(&isPrune, "prune", false, "remove generated files of tables which no longer exist (generate, check and watch mode)")
+	flag.DurationVar(&interval, "watch-interval", time.Second, "polling interval of -watch")
 	flag.Parse()
 
-	if rootDir == "" {
+	mode := flag.Arg(0)
+	// render reads ddl from stdin, so the project root is optional
+	if rootDir == "" && mode != "render" {
 		flag.Usage()
 		return nil
 	}
 
-	mode := flag.Arg(0)
-	if mode == "yaml" {
+	cfg, err := remodel.LoadConfig(rootDir, configPath)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	// flags given explicitly take precedence over the config file
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "module":
+			cfg.Module = moduleName
+		case "proto":
+			cfg.Proto = isProtoc
+		case "json":
+			cfg.JSON = isJSON
+		case "workers":
+			cfg.Workers = workers
+		case "prune":
+			cfg.Prune = isPrune
+		}
+	})
+	if cfg.Tables, err = remodel.ParseTableFilter(tables); err != nil {
+		return errors.Trace(err)
+	}
+	if isDryRun {
+		cfg.Writer = &remodel.DiffWriter{Writer: os.Stdout, RootDir: rootDir}
+	}
+
+	if isWatch {
+		if cfg.Module == "" {
+			flag.Usage()
+			return nil
+		}
+		stop := make(chan struct{})
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+		go func() {
+			<-sig
+			close(stop)
+		}()
+		return errors.Trace(remodel.Watch(cfg, interval, stop))
+	}
+
+	switch mode {
+	case "render":
+		return errors.Trace(render(cfg, remodel.Layer(flag.Arg(1))))
+	case "yaml":
 		s := &remodel.Tables{}
-		return errors.Trace(s.Output(rootDir))
+		return errors.Trace(s.Output(cfg))
+	case "generate", "all":
+		if cfg.Module == "" {
+			flag.Usage()
+			return nil
+		}
+		s := &remodel.Tables{}
+		return errors.Trace(s.Generate(cfg))
+	case "lint":
+		s := &remodel.Tables{}
+		ds, err := s.Lint(cfg)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		count := 0
+		for _, d := range ds {
+			fmt.Println(d)
+			if d.Severity == remodel.SeverityError {
+				count++
+			}
+		}
+		if count > 0 {
+			return errors.Errorf("%d lint error(s)", count)
+		}
+		return nil
+	case "check":
+		if cfg.Module == "" {
+			flag.Usage()
+			return nil
+		}
+		s := &remodel.Tables{}
+		stale, err := s.Check(cfg)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if len(stale) > 0 {
+			for _, path := range stale {
+				fmt.Printf("stale: %s\n", path)
+			}
+			return errors.Errorf("%d generated file(s) are stale, please run generate", len(stale))
+		}
+		return nil
 	}
 
 	ts := &remodel.Tables{}
-	if err := ts.Load(rootDir); err != nil {
+	if err := ts.Load(cfg); err != nil {
 		return errors.Trace(err)
 	}
 
 	switch mode {
 	case "entity":
 		s := ts.Entities()
-		return errors.Trace(s.Output(rootDir, isJSON, isJSON))
+		return errors.Trace(s.Output(cfg))
 	case "dao":
-		if moduleName == "" {
+		if cfg.Module == "" {
 			flag.Usage()
 			return nil
 		}
 		s := ts.Daos()
-		return errors.Trace(s.Output(rootDir, moduleName))
+		return errors.Trace(s.Output(cfg))
 	case "model":
-		if moduleName == "" {
+		if cfg.Module == "" {
 			flag.Usage()
 			return nil
 		}
 		s := ts.Models()
-		return errors.Trace(s.Output(rootDir, moduleName))
+		return errors.Trace(s.Output(cfg))
 	default:
-		fmt.Println("please input mode: [yaml|entity|dao|model]")
+		fmt.Println("please input mode: [yaml|entity|dao|model|generate|check|lint|render]")
 		return nil
 	}
 }
+
+// render converts the first CREATE TABLE on stdin to the source of the layer on stdout.
+func render(cfg *remodel.Config, layer remodel.Layer) error {
+	ddl, err := ioutil.ReadAll(os.Stdin)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	b, err := remodel.Render(cfg, ddl, layer)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	_, err = os.Stdout.Write(b)
+	return errors.Trace(err)
+}
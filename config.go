@@ -0,0 +1,172 @@
+package remodel
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/juju/errors"
+	"gopkg.in/yaml.v2"
+)
+
+type Config struct {
+	Version   int           `yaml:"version"`
+	Module    string        `yaml:"module"`
+	Proto     bool          `yaml:"proto"`
+	JSON      bool          `yaml:"json"`
+	Entity    LayerConfig   `yaml:"entity"`
+	Dao       LayerConfig   `yaml:"dao"`
+	Model     LayerConfig   `yaml:"model"`
+	Libraries LibraryConfig `yaml:"libraries"`
+	// Workers is the number of concurrent code generations, 0 means the number of CPUs.
+	Workers int `yaml:"workers"`
+	// Prune removes generated files of tables which no longer exist on generate.
+	Prune bool `yaml:"prune"`
+	// Nullable is how NULL of nullable columns is held in Go: empty, "pointer" or "sql".
+	Nullable NullableMode `yaml:"nullable"`
+	// Decimal is the type of DECIMAL columns like github.com/shopspring/decimal.Decimal, empty means string.
+	// The type must implement encoding.TextMarshaler and encoding.TextUnmarshaler.
+	Decimal string `yaml:"decimal"`
+	// Lint is the naming rules checked on generate and lint.
+	Lint LintConfig `yaml:"lint"`
+
+	RootDir string     `yaml:"-"`
+	Writer  FileWriter `yaml:"-"`
+	// Tables are glob patterns of table names to output, empty means every table.
+	Tables []string `yaml:"-"`
+}
+
+// LayerConfig is the output of a Go layer.
+// Dir is a slash separated path relative to the root directory, and it is also the import path under the module.
+type LayerConfig struct {
+	Dir     string `yaml:"dir"`
+	Package string `yaml:"package"`
+}
+
+type LibraryConfig struct {
+	Errors   string `yaml:"errors"`
+	Log      string `yaml:"log"`
+	Rapidash string `yaml:"rapidash"`
+}
+
+// NewConfig returns the default configuration used when no config file exists.
+func NewConfig(rootDir string) *Config {
+	return &Config{
+		Version: ConfigVersion,
+		Entity:  LayerConfig{Dir: EntityPackageName, Package: EntityPackageName},
+		Dao:     LayerConfig{Dir: DaoPackageName, Package: DaoPackageName},
+		Model:   LayerConfig{Dir: ModelPackageName, Package: ModelPackageName},
+		Libraries: LibraryConfig{
+			Errors:   ErrorsLib,
+			Log:      LogLib,
+			Rapidash: RapidashLib,
+		},
+		Lint:    newLintConfig(),
+		RootDir: rootDir,
+		Writer:  &DiskWriter{},
+	}
+}
+
+// LoadConfig reads the config file on top of the defaults.
+// If configPath is empty, (rootDir)/remodel.yml is used when it exists.
+func LoadConfig(rootDir, configPath string) (*Config, error) {
+	c := NewConfig(rootDir)
+	if configPath == "" {
+		configPath = filepath.Join(rootDir, ConfigFileName)
+		if _, err := os.Stat(configPath); os.IsNotExist(err) {
+			return c, nil
+		}
+	}
+
+	b, err := ioutil.ReadFile(configPath)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	c.Version = 0
+	// a typo of a key must not fall back to the default silently
+	if err := yaml.UnmarshalStrict(b, c); err != nil {
+		return nil, errors.Annotatef(err, "cannot decode %s", configPath)
+	}
+	if err := c.validate(); err != nil {
+		return nil, errors.Annotatef(err, "invalid config %s", configPath)
+	}
+	return c, nil
+}
+
+func (c *Config) validate() error {
+	if c.Version != ConfigVersion {
+		return errors.Errorf("unsupported version: %d (expected %d)", c.Version, ConfigVersion)
+	}
+	if err := c.Nullable.validate(); err != nil {
+		return errors.Trace(err)
+	}
+	if err := c.Lint.validate(); err != nil {
+		return errors.Trace(err)
+	}
+	// in a fixed order, so the same config always reports the same error
+	for _, layer := range []struct {
+		name string
+		LayerConfig
+	}{{"entity", c.Entity}, {"dao", c.Dao}, {"model", c.Model}} {
+		name, l := layer.name, layer.LayerConfig
+		if l.Package == "" {
+			return errors.Errorf("empty package name of %s", name)
+		}
+		if l.Dir == "" {
+			return errors.Errorf("empty directory of %s", name)
+		}
+		if path.IsAbs(l.Dir) || strings.HasPrefix(path.Clean(l.Dir), "..") {
+			return errors.Errorf("directory of %s must be inside of the root directory: %s", name, l.Dir)
+		}
+	}
+	return nil
+}
+
+// ParseTableFilter splits comma separated glob patterns like "users,user_*".
+func ParseTableFilter(s string) ([]string, error) {
+	var patterns []string
+	for _, p := range strings.Split(s, ",") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		if _, err := path.Match(p, ""); err != nil {
+			return nil, errors.Annotatef(err, "invalid table pattern %q", p)
+		}
+		patterns = append(patterns, p)
+	}
+	return patterns, nil
+}
+
+func (c *Config) matchTable(name string) bool {
+	if len(c.Tables) == 0 {
+		return true
+	}
+	for _, p := range c.Tables {
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Config) workers() int {
+	if c.Workers > 0 {
+		return c.Workers
+	}
+	return runtime.NumCPU()
+}
+
+// importPath returns the import path of the output directory of a layer.
+// The package name may differ from the directory, so it is never a part of the path.
+func (c *Config) importPath(l LayerConfig) string {
+	return path.Join(c.Module, l.Dir)
+}
+
+func (c *Config) layerDir(l LayerConfig) (string, error) {
+	dir, err := filepath.Abs(filepath.Join(c.RootDir, filepath.FromSlash(l.Dir)))
+	return dir, errors.Trace(err)
+}
@@ -0,0 +1,169 @@
+package remodel
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/juju/errors"
+	"github.com/yuki-eto/remodel/assert"
+)
+
+func TestLoadConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "remodel")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	t.Run("default", func(t *testing.T) {
+		cfg, err := LoadConfig(dir, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equals(t, cfg.RootDir, dir)
+		assert.Equals(t, cfg.Entity.Package, EntityPackageName)
+		assert.Equals(t, cfg.Libraries.Errors, ErrorsLib)
+	})
+
+	t.Run("discover", func(t *testing.T) {
+		body := `
+version: 1
+module: github.com/foo/bar
+json: true
+dao:
+  dir: infra/repository
+  package: repository
+libraries:
+  log: log
+`
+		if err := ioutil.WriteFile(filepath.Join(dir, ConfigFileName), []byte(body), 0644); err != nil {
+			t.Fatal(err)
+		}
+		cfg, err := LoadConfig(dir, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equals(t, cfg.Module, "github.com/foo/bar")
+		assert.True(t, cfg.JSON)
+		assert.False(t, cfg.Proto)
+		assert.Equals(t, cfg.Dao.Package, "repository")
+		assert.Equals(t, cfg.Entity.Package, EntityPackageName)
+		assert.Equals(t, cfg.Libraries.Log, "log")
+		assert.Equals(t, cfg.Libraries.Rapidash, RapidashLib)
+		assert.Equals(t, cfg.importPath(cfg.Dao), "github.com/foo/bar/infra/repository")
+		assert.Equals(t, cfg.importPath(cfg.Entity), "github.com/foo/bar/entity")
+		daoDir, err := cfg.layerDir(cfg.Dao)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equals(t, daoDir, filepath.Join(dir, "infra", "repository"))
+	})
+
+	t.Run("unsupported_version", func(t *testing.T) {
+		path := filepath.Join(dir, "other.yml")
+		if err := ioutil.WriteFile(path, []byte("module: foo\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		_, err := LoadConfig(dir, path)
+		assert.NotEquals(t, err, nil)
+	})
+
+	t.Run("outside_of_root", func(t *testing.T) {
+		path := filepath.Join(dir, "other.yml")
+		if err := ioutil.WriteFile(path, []byte("version: 1\nmodel:\n  dir: ../model\n  package: model\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		_, err := LoadConfig(dir, path)
+		assert.NotEquals(t, err, nil)
+	})
+
+	t.Run("invalid_layers", func(t *testing.T) {
+		path := filepath.Join(dir, "other.yml")
+		body := "version: 1\nentity:\n  dir: ../entity\ndao:\n  dir: ../dao\nmodel:\n  dir: ../model\n"
+		if err := ioutil.WriteFile(path, []byte(body), 0644); err != nil {
+			t.Fatal(err)
+		}
+		// the layers are validated in order, so the error does not change from run to run
+		for n := 0; n < 10; n++ {
+			_, err := LoadConfig(dir, path)
+			assert.NotEquals(t, err, nil)
+			assert.Equals(t, errors.Cause(err).Error(), "directory of entity must be inside of the root directory: ../entity")
+		}
+	})
+
+	t.Run("unknown_key", func(t *testing.T) {
+		path := filepath.Join(dir, "other.yml")
+		for _, body := range []string{"version: 1\nentitty:\n  dir: entity\n", "version: 1\nnulable: pointer\n"} {
+			if err := ioutil.WriteFile(path, []byte(body), 0644); err != nil {
+				t.Fatal(err)
+			}
+			_, err := LoadConfig(dir, path)
+			assert.NotEquals(t, err, nil)
+		}
+	})
+
+	t.Run("unknown_nullable", func(t *testing.T) {
+		path := filepath.Join(dir, "other.yml")
+		if err := ioutil.WriteFile(path, []byte("version: 1\nnullable: optional\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		_, err := LoadConfig(dir, path)
+		assert.NotEquals(t, err, nil)
+	})
+
+	t.Run("lint", func(t *testing.T) {
+		path := filepath.Join(dir, "other.yml")
+		body := `
+version: 1
+lint:
+  rules:
+    plural_table: warning
+    snake_case: error
+  waivers:
+    user_settings.settings: [singular_column]
+`
+		if err := ioutil.WriteFile(path, []byte(body), 0644); err != nil {
+			t.Fatal(err)
+		}
+		cfg, err := LoadConfig(dir, path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equals(t, cfg.Lint.Rules[LintPluralTable], SeverityWarning)
+		assert.Equals(t, cfg.Lint.Rules[LintSnakeCase], SeverityError)
+		// rules which are not written keep the defaults
+		assert.Equals(t, cfg.Lint.Rules[LintSingularColumn], SeverityError)
+		assert.Equals(t, cfg.Lint.severity(LintSingularColumn, "user_settings", "settings"), SeverityOff)
+
+		if err := ioutil.WriteFile(path, []byte("version: 1\nlint:\n  rules:\n    plural_tables: error\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		_, err = LoadConfig(dir, path)
+		assert.NotEquals(t, err, nil)
+	})
+
+	t.Run("not_found", func(t *testing.T) {
+		_, err := LoadConfig(dir, filepath.Join(dir, "missing.yml"))
+		assert.NotEquals(t, err, nil)
+	})
+}
+
+func TestConfig_matchTable(t *testing.T) {
+	cfg := NewConfig("")
+	assert.True(t, cfg.matchTable("users"))
+
+	patterns, err := ParseTableFilter("users, user_*,")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equals(t, patterns, []string{"users", "user_*"})
+	cfg.Tables = patterns
+	assert.True(t, cfg.matchTable("users"))
+	assert.True(t, cfg.matchTable("user_friends"))
+	assert.False(t, cfg.matchTable("items"))
+
+	_, err = ParseTableFilter("[")
+	assert.NotEquals(t, err, nil)
+}
@@ -31,6 +31,7 @@ const (
 	TinyText   ColumnType = "tinytext"
 	Text       ColumnType = "text"
 	Enum       ColumnType = "enum"
+	JSON       ColumnType = "json"
 
 	// Golang types
 	Uint64      EntityType = "uint64"
@@ -45,9 +46,11 @@ const (
 	Float64     EntityType = "float64"
 	Float32     EntityType = "float32"
 	TimePtr     EntityType = "*time.Time"
+	TimeValue   EntityType = "time.Time"
 	String      EntityType = "string"
 	ByteSlice   EntityType = "[]byte"
 	StringSlice EntityType = "[]string"
+	RawJSON     EntityType = "json.RawMessage"
 
 	// outside library for generate code
 	ErrorsLib   = "github.com/juju/errors"
@@ -56,4 +59,17 @@ const (
 
 	EntityPackageName = "entity"
 	DaoPackageName    = "dao"
+	ModelPackageName  = "model"
+
+	// generated layers
+	LayerYAML   Layer = "yaml"
+	LayerEntity Layer = "entity"
+	LayerProto  Layer = "proto"
+	LayerDao    Layer = "dao"
+	LayerModel  Layer = "model"
+	LayerPrune  Layer = "prune"
+
+	// project config file
+	ConfigFileName = "remodel.yml"
+	ConfigVersion  = 1
 )
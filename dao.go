@@ -1,10 +1,9 @@
 package remodel
 
 import (
+	"bytes"
 	"fmt"
 	"io"
-	"log"
-	"os"
 	"path/filepath"
 	"strings"
 
@@ -14,13 +13,15 @@ import (
 )
 
 type Dao struct {
-	Name       string
-	TableName  string
-	SliceName  string
-	Indexes    []*DaoIndex
-	Fields     []*DaoField
-	IsReadOnly bool
-	HasTime    bool
+	Name        string
+	Comment     string
+	TableName   string
+	SliceName   string
+	Indexes     []*DaoIndex
+	Fields      []*DaoField
+	PrimaryKeys []*DaoField
+	IsReadOnly  bool
+	HasTime     bool
 }
 
 type Daos []*Dao
@@ -39,33 +40,52 @@ type DaoFindMethod struct {
 	FindColumns []string
 }
 
-func (s *Daos) Output(rootPath, moduleName string) error {
-	for _, d := range *s {
-		daoPath, err := filepath.Abs(filepath.Join(rootPath, "dao", fmt.Sprintf("%s.go", strcase.ToSnake(d.Name))))
-		if err != nil {
-			return errors.Trace(err)
+func (s *Daos) Output(cfg *Config) error {
+	files, errs := renderParallel(cfg, len(*s), func(i int) ([]*generatedFile, GenerateErrors) {
+		d := (*s)[i]
+		if !cfg.matchTable(d.TableName) {
+			return nil, nil
 		}
-		out, err := os.Create(daoPath)
+		var errs GenerateErrors
+		f, err := d.render(cfg)
 		if err != nil {
-			return errors.Trace(err)
-		}
-		if err := d.generateCode(out, moduleName); err != nil {
-			return errors.Trace(err)
-		}
-		if err := out.Close(); err != nil {
-			return errors.Trace(err)
-		}
-		if err := applyGoimports(daoPath); err != nil {
-			return errors.Trace(err)
+			errs.add(d.TableName, LayerDao, err)
+			return nil, errs
 		}
+		return []*generatedFile{f}, nil
+	})
+
+	errs = append(errs, writeGenerated(cfg, files)...)
+	return errs.orNil()
+}
 
-		log.Printf("output: %s", daoPath)
+func (d *Dao) codePath(cfg *Config) (string, error) {
+	dir, err := cfg.layerDir(cfg.Dao)
+	if err != nil {
+		return "", errors.Trace(err)
 	}
+	return filepath.Join(dir, fmt.Sprintf("%s.go", strcase.ToSnake(d.Name))), nil
+}
 
-	return nil
+func (d *Dao) render(cfg *Config) (*generatedFile, error) {
+	daoPath, err := d.codePath(cfg)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	out := &bytes.Buffer{}
+	out.WriteString(generatedCodeHeader)
+	if err := d.generateCode(out, cfg); err != nil {
+		return nil, errors.Trace(err)
+	}
+	b, err := applyGoimports(daoPath, out.Bytes())
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	return &generatedFile{table: d.TableName, layer: LayerDao, path: daoPath, body: b}, nil
 }
 
-func (d *DaoIndex) findMethods(moduleName, entityName, sliceName string, p *pluralize.Client) []*DaoFindMethod {
+func (d *DaoIndex) findMethods(entityPackage, entityName, sliceName string, p *pluralize.Client) []*DaoFindMethod {
 	var methods []*DaoFindMethod
 
 	var (
@@ -73,7 +93,6 @@ func (d *DaoIndex) findMethods(moduleName, entityName, sliceName string, p *plur
 		fieldNames []string
 	)
 	indexSize := len(d.Columns)
-	entityPackage := fmt.Sprintf("%s/%s", moduleName, EntityPackageName)
 	returnTypeSingle := ptr(qual(entityPackage, entityName))
 	returnTypeSlice := qual(entityPackage, sliceName)
 	for _, c := range d.Columns {
@@ -106,7 +125,7 @@ func (d *DaoIndex) findMethods(moduleName, entityName, sliceName string, p *plur
 			if col.Name == "user_id" {
 				continue
 			}
-			m.Args = append(m.Args, i(fmt.Sprintf("k%d", j)).Id(string(col.EntityType)))
+			m.Args = append(m.Args, i(fmt.Sprintf("k%d", j)).Add(argType(entityPackage, entityName, col)))
 			j++
 		}
 		methods = append(methods, m)
@@ -118,7 +137,7 @@ func (d *DaoIndex) findMethods(moduleName, entityName, sliceName string, p *plur
 			}
 			m := &DaoFindMethod{
 				Name:        "FindBy" + findInField,
-				Args:        []code{i("k0").Index().Id(string(c.EntityType))},
+				Args:        []code{i("k0").Index().Add(argType(entityPackage, entityName, c))},
 				IsSliceArg:  true,
 				IsSlice:     true,
 				ReturnType:  returnTypeSlice,
@@ -131,35 +150,137 @@ func (d *DaoIndex) findMethods(moduleName, entityName, sliceName string, p *plur
 	return methods
 }
 
+// argType returns the type of an argument of find methods, enums are the types defined in the entity package.
+// Nullable columns are found by a value which is not NULL.
+func argType(entityPackage, entityName string, c *Column) code {
+	var named code
+	if len(c.EnumValues) > 0 {
+		named = qual(entityPackage, enumTypeName(entityName, toFieldName(c.Name)))
+	}
+	return valueTypeCode(c.goType(), named)
+}
+
 type DaoField struct {
-	Name       string
-	ColumnName string
+	Name            string
+	ColumnName      string
+	FieldType       EntityType
+	Null            NullableMode
+	GoType          string
+	IsAutoIncrement bool
+	IsGenerated     bool
+	NamedType       string
+	Comment         string
+}
+
+// value converts a value of the field to the type which rapidash accepts.
+func (f *DaoField) value(v *statement) *statement {
+	if f.NamedType != "" {
+		return i(string(f.FieldType)).Call(v)
+	}
+	return v
+}
+
+// columnValue converts the field of the entity to the type which rapidash accepts, nullable fields are pointers.
+// A field of go_type is the JSON or the text made by marshalCodes.
+func (f *DaoField) columnValue(entityPackage string, v *statement) *statement {
+	switch {
+	case f.GoType != "":
+		return marshalledValue(f.Name, f.GoType, f.FieldType)
+	case f.FieldType == RawJSON:
+		return idx().Byte().Call(v)
+	case f.Null == NullableSQL:
+		return qual(entityPackage, sqlNullName(f.FieldType)+"Ptr").Call(v)
+	case f.Null == NullablePointer && f.NamedType != "":
+		return jparens(ptr(i(string(f.FieldType)))).Call(v)
+	case f.Null == NullablePointer:
+		return v
+	}
+	return f.value(v)
+}
+
+// nowValue returns now as the value of the time field, f is nil when the table does not have the column.
+func (f *DaoField) nowValue() code {
+	if f == nil || f.FieldType == TimePtr || f.Null == NullablePointer {
+		return addr(i("now"))
+	}
+	if f.Null == NullableSQL {
+		return qual("database/sql", "NullTime").Add(vals(cmap{i("Time"): i("now"), i("Valid"): bools(true)}))
+	}
+	return i("now")
+}
+
+// marshalCodes marshals the fields of go_type to JSON or text for the update.
+func (d *Dao) marshalCodes(errorsLib string) []code {
+	var codes []code
+	for _, f := range d.Fields {
+		if f.GoType != "" && !f.IsGenerated {
+			codes = append(codes, marshalCode(errorsLib, f.Name, f.GoType, f.FieldType)...)
+		}
+	}
+	return codes
+}
+
+// field returns the field of the column, nil when the table does not have it.
+func (d *Dao) field(columnName string) *DaoField {
+	for _, f := range d.Fields {
+		if f.ColumnName == columnName {
+			return f
+		}
+	}
+	return nil
+}
+
+func (d *Dao) isCompositeKey() bool {
+	return len(d.PrimaryKeys) > 1
+}
+
+// isAutoIncrementKey reports whether the database assigns the primary key on insert.
+func (d *Dao) isAutoIncrementKey() bool {
+	return len(d.PrimaryKeys) == 1 && d.PrimaryKeys[0].IsAutoIncrement
 }
 
 func (d *Dao) fromTable(t *Table) {
+	p := pluralize.NewClient()
+	d.Name = strcase.ToCamel(p.Singular(t.Name))
+	d.SliceName = strcase.ToCamel(t.Name)
+	d.TableName = t.Name
+	d.Comment = t.Comment
+	d.IsReadOnly = t.IsReadOnly
+
 	columnMap := map[string]*Column{}
 	for _, c := range t.Columns {
 		columnMap[c.Name] = c
 		field := &DaoField{
-			ColumnName: c.Name,
+			ColumnName:      c.Name,
+			FieldType:       c.goType(),
+			Null:            c.nullMode(),
+			IsAutoIncrement: c.IsAutoIncrement,
+			IsGenerated:     c.IsGenerated,
+			Comment:         c.Comment,
 		}
+		field.GoType = c.fieldGoType()
 		fieldName := strcase.ToCamel(c.Name)
 		if strings.HasSuffix(fieldName, "Id") {
 			l := len(fieldName)
 			fieldName = fieldName[:l-2] + "ID"
 		}
 		field.Name = fieldName
+		if len(c.EnumValues) > 0 {
+			field.NamedType = enumTypeName(d.Name, fieldName)
+		}
 		if c.EntityType == TimePtr {
 			d.HasTime = true
 		}
 		d.Fields = append(d.Fields, field)
 	}
 
-	p := pluralize.NewClient()
-	d.Name = strcase.ToCamel(p.Singular(t.Name))
-	d.SliceName = strcase.ToCamel(t.Name)
-	d.TableName = t.Name
-	d.IsReadOnly = t.IsReadOnly
+	for _, c := range t.primaryKeyColumns() {
+		for _, f := range d.Fields {
+			if f.ColumnName == c.Name {
+				d.PrimaryKeys = append(d.PrimaryKeys, f)
+			}
+		}
+	}
 
 	d.Indexes = []*DaoIndex{}
 	for _, i := range t.Indexes {
@@ -174,15 +295,17 @@ func (d *Dao) fromTable(t *Table) {
 	}
 }
 
-func (d *Dao) generateCode(writer io.Writer, moduleName string) error {
+func (d *Dao) generateCode(writer io.Writer, cfg *Config) error {
 	p := pluralize.NewClient()
-	f := newFile("dao")
-
-	entityPackage := fmt.Sprintf("%s/%s", moduleName, EntityPackageName)
-	f.ImportName(entityPackage, EntityPackageName)
-	f.ImportName(RapidashLib, "rapidash")
-	f.ImportName(LogLib, "log")
-	f.ImportName(ErrorsLib, "errors")
+	f := newFile(cfg.Dao.Package)
+
+	errorsLib := cfg.Libraries.Errors
+	rapidashLib := cfg.Libraries.Rapidash
+	entityPackage := cfg.importPath(cfg.Entity)
+	f.ImportName(entityPackage, cfg.Entity.Package)
+	f.ImportName(rapidashLib, "rapidash")
+	f.ImportName(cfg.Libraries.Log, "log")
+	f.ImportName(errorsLib, "errors")
 	if d.HasTime {
 		f.ImportName("time", "time")
 	}
@@ -196,8 +319,8 @@ func (d *Dao) generateCode(writer io.Writer, moduleName string) error {
 	sliceAndError := list(sliceEntity, jerr())
 
 	returnNil := rtn().Nil()
-	returnErr := rtn(traceErr())
-	returnNilAndErr := rtn(null(), traceErr())
+	returnErr := rtn(traceErr(errorsLib))
+	returnNilAndErr := rtn(null(), traceErr(errorsLib))
 
 	// define interface
 	var methodDefines []code
@@ -234,8 +357,12 @@ func (d *Dao) generateCode(writer io.Writer, moduleName string) error {
 		})
 	}
 	findMethodNames := map[string]struct{}{}
+	if d.isCompositeKey() {
+		findMethods = append(findMethods, d.findByPrimaryKeyMethod(entityPackage, ptrEntity))
+		findMethodNames["FindByPrimaryKey"] = struct{}{}
+	}
 	for _, index := range d.Indexes {
-		mds := index.findMethods(moduleName, d.Name, d.SliceName, p)
+		mds := index.findMethods(entityPackage, d.Name, d.SliceName, p)
 		for _, m := range mds {
 			if _, exists := findMethodNames[m.Name]; exists {
 				continue
@@ -245,25 +372,34 @@ func (d *Dao) generateCode(writer io.Writer, moduleName string) error {
 		}
 	}
 
+	fieldMap := map[string]*DaoField{}
+	for _, field := range d.Fields {
+		fieldMap[field.ColumnName] = field
+	}
+
 	structName := d.Name + "Impl"
 	for _, m := range findMethods {
+		methodDefines = append(methodDefines, comments(findMethodComment(m, fieldMap))...)
 		methodDefines = append(methodDefines, i(m.Name).Params(m.Args...).Params(m.ReturnType, jerr()))
 	}
+	for _, c := range comments(d.Comment) {
+		f.Add(c)
+	}
 	f.Type().Id(d.Name).Interface(methodDefines...).Line()
 
-	qb := qual(RapidashLib, "QueryBuilder")
+	qb := qual(rapidashLib, "QueryBuilder")
 	structFields := []code{
 		i("tableName").String(),
-		i("txGetter").Func().Call().Params(ptr(qual(RapidashLib, "Tx")), jerr()),
+		i("txGetter").Func().Call().Params(ptr(qual(rapidashLib, "Tx")), jerr()),
 		i("qb").Func().Call().Params(ptr(qb)),
 	}
 	structMap := cmap{
 		i("tableName"): lit(d.TableName),
-		i("txGetter"): fn().Call().Params(ptr(qual(RapidashLib, "Tx")), jerr()).Block(
+		i("txGetter"): fn().Call().Params(ptr(qual(rapidashLib, "Tx")), jerr()).Block(
 			rtn(i("txGetter").Call(lit(d.TableName))),
 		),
 		i("qb"): fn().Call().Params(ptr(qb)).Block(
-			rtn(qual(RapidashLib, "NewQueryBuilder").Call(lit(d.TableName))),
+			rtn(qual(rapidashLib, "NewQueryBuilder").Call(lit(d.TableName))),
 		),
 	}
 	isUserTable := !d.IsReadOnly && strings.HasPrefix(d.TableName, "user_")
@@ -275,7 +411,7 @@ func (d *Dao) generateCode(writer io.Writer, moduleName string) error {
 		)
 		structMap[i("userIDGetter")] = i("userIDGetter")
 		structMap[i("uqb")] = fn().Call().Params(ptr(qb)).Block(
-			rtn(qual(RapidashLib, "NewQueryBuilder").Call(lit(d.TableName)).Dot("Eq").Call(lit("user_id"), i("userIDGetter").Call())),
+			rtn(qual(rapidashLib, "NewQueryBuilder").Call(lit(d.TableName)).Dot("Eq").Call(lit("user_id"), i("userIDGetter").Call())),
 		)
 	}
 
@@ -284,7 +420,7 @@ func (d *Dao) generateCode(writer io.Writer, moduleName string) error {
 
 	// define methods
 	// instantiate
-	txGetter := fn().Call(str()).Params(ptr(qual(RapidashLib, "Tx")), jerr())
+	txGetter := fn().Call(str()).Params(ptr(qual(rapidashLib, "Tx")), jerr())
 	params := []code{
 		i("txGetter").Add(txGetter),
 	}
@@ -303,7 +439,6 @@ func (d *Dao) generateCode(writer io.Writer, moduleName string) error {
 	checkErrAndReturnNilAndErr := ifErr().Block(returnNilAndErr)
 	queryBuilder := i("b").Op(":=").Id("d").Dot("qb").Call()
 	userQueryBuilder := i("b").Op(":=").Id("d").Dot("uqb").Call()
-	idQueryBuilder := queryBuilder.Clone().Dot("Eq").Call(lit("id"), i("e").Dot("ID"))
 	tx := i("tx")
 	if d.IsReadOnly {
 		// FindsAll
@@ -316,46 +451,54 @@ func (d *Dao) generateCode(writer io.Writer, moduleName string) error {
 			),
 			rtn(ptr(i("e")), null()),
 		)).Line()
+	} else if !d.isAutoIncrementKey() {
+		d.generateKeyLookupCode(f, cfg, structName)
 	} else {
 		// Save
+		pk := d.PrimaryKeys[0]
+		idQueryBuilder := queryBuilder.Clone().Dot("Eq").Call(lit(pk.ColumnName), i("e").Dot(pk.Name))
 		m := cmap{}
 		var userIDSetter code
 		if isUserTable {
 			userIDSetter = i("e").Dot("UserID").Op("=").Id("d").Dot("userIDGetter").Call()
 		}
 		for _, field := range d.Fields {
-			if field.ColumnName == "id" || field.ColumnName == "created_at" || field.ColumnName == "user_id" {
+			if field.ColumnName == pk.ColumnName || field.ColumnName == "created_at" || field.ColumnName == "user_id" || field.IsGenerated {
 				continue
 			}
-			m[lit(field.ColumnName)] = i("e").Dot(field.Name)
+			m[lit(field.ColumnName)] = field.columnValue(entityPackage, i("e").Dot(field.Name))
 		}
-		f.Add(pfn("d", structName).Id("Save").Params(entityParam).Error().Block(
+		saveCodes := []code{
 			txGetterCall,
 			checkErrAndReturnErr,
 			i("now").Op(":=").Qual("time", "Now").Call(),
-			i("e").Dot("UpdatedAt").Op("=").Add(addr(i("now"))),
-			ifa(i("e").Dot("ID"), "==", lit(0)).Block(
+			i("e").Dot("UpdatedAt").Op("=").Add(d.field("updated_at").nowValue()),
+			ifa(i("e").Dot(pk.Name), "==", pk.FieldType.zeroValue()).Block(
 				userIDSetter,
-				i("e").Dot("CreatedAt").Op("=").Add(addr(i("now"))),
+				i("e").Dot("CreatedAt").Op("=").Add(d.field("created_at").nowValue()),
 				list(i("id"), i("err")).Op(":=").Add(tx.Clone().Dot("CreateByTable").Call(tableName, i("e"))),
 				ifErr().Block(returnErr),
-				i("e").Dot("ID").Op("=").Id("uint64").Params(i("id")),
+				i("e").Dot(pk.Name).Op("=").Id(string(pk.FieldType)).Params(i("id")),
 				returnNil,
 			),
 			idQueryBuilder,
+		}
+		saveCodes = append(saveCodes, d.marshalCodes(errorsLib)...)
+		saveCodes = append(saveCodes,
 			i("m").Op(":=").Map(str()).Interface().Add(vals(m)),
 			ifxErr(tx.Clone().Dot("UpdateByQueryBuilder").Call(i("b"), i("m"))).Block(
 				returnErr,
 			),
 			returnNil,
-		)).Line()
+		)
+		f.Add(pfn("d", structName).Id("Save").Params(entityParam).Error().Block(saveCodes...)).Line()
 
 		// Delete
 		f.Add(pfn("d", structName).Id("Delete").Params(entityParam).Error().Block(
 			txGetterCall,
 			checkErrAndReturnErr,
-			ifa(i("e").Dot("ID"), "==", lit(0)).Block(
-				rtn(qual(ErrorsLib, "New").Call(lit("cannot delete without identifier"))),
+			ifa(i("e").Dot(pk.Name), "==", pk.FieldType.zeroValue()).Block(
+				rtn(qual(errorsLib, "New").Call(lit("cannot delete without identifier"))),
 			),
 			idQueryBuilder,
 			ifxErr(tx.Clone().Dot("DeleteByQueryBuilder").Call(i("b"))).Block(
@@ -376,14 +519,26 @@ func (d *Dao) generateCode(writer io.Writer, moduleName string) error {
 			q = userQueryBuilder.Clone()
 		}
 		if m.IsSliceArg {
-			q.Dot("In").Call(lit(m.FindColumns[0]), i("k0"))
+			field := fieldMap[m.FindColumns[0]]
+			if field.NamedType != "" {
+				// rapidash accepts only the slice of primitive types
+				codes = append(codes,
+					i("values").Op(":=").Make(idx().Id(string(field.FieldType)), lit(0), size(i("k0"))),
+					forEachV("v", i("k0")).Block(
+						i("values").Op("=").Append(i("values"), field.value(i("v"))),
+					),
+				)
+				q.Dot("In").Call(lit(m.FindColumns[0]), i("values"))
+			} else {
+				q.Dot("In").Call(lit(m.FindColumns[0]), i("k0"))
+			}
 		} else {
 			j := 0
 			for _, c := range m.FindColumns {
 				if c == "user_id" {
 					continue
 				}
-				q.Dot("Eq").Call(lit(c), i(fmt.Sprintf("k%d", j)))
+				q.Dot("Eq").Call(lit(c), fieldMap[c].value(i(fmt.Sprintf("k%d", j))))
 				j++
 			}
 		}
@@ -397,7 +552,8 @@ func (d *Dao) generateCode(writer io.Writer, moduleName string) error {
 		if m.IsSlice {
 			codes = append(codes, rtn(ptr(i("e")), null()))
 		} else {
-			codes = append(codes, ifa(i("e").Dot("ID"), "==", lit(0)).Block(
+			pk := d.PrimaryKeys[0]
+			codes = append(codes, ifa(i("e").Dot(pk.Name), "==", pk.FieldType.zeroValue()).Block(
 				rtn(null(), null()),
 			))
 			codes = append(codes, rtn(i("e"), null()))
@@ -407,3 +563,144 @@ func (d *Dao) generateCode(writer io.Writer, moduleName string) error {
 
 	return errors.Trace(f.Render(writer))
 }
+
+// findMethodComment describes the columns of a find method by their comments.
+// It returns an empty string when no column has a comment.
+func findMethodComment(m *DaoFindMethod, fieldMap map[string]*DaoField) string {
+	var (
+		columns    []string
+		hasComment bool
+	)
+	for _, c := range m.FindColumns {
+		if c == "user_id" {
+			continue
+		}
+		field := fieldMap[c]
+		if field.Comment == "" {
+			columns = append(columns, c)
+			continue
+		}
+		hasComment = true
+		columns = append(columns, fmt.Sprintf("%s (%s)", c, strings.Join(strings.Fields(field.Comment), " ")))
+	}
+	if !hasComment {
+		return ""
+	}
+	return fmt.Sprintf("%s finds by %s.", m.Name, strings.Join(columns, ", "))
+}
+
+// findByPrimaryKeyMethod finds a record by every column of the composite primary key.
+// user_id is given by userIDGetter like the other find methods.
+func (d *Dao) findByPrimaryKeyMethod(entityPackage string, returnType code) *DaoFindMethod {
+	m := &DaoFindMethod{
+		Name:        "FindByPrimaryKey",
+		Args:        []code{},
+		IsSliceArg:  false,
+		IsSlice:     false,
+		ReturnType:  returnType,
+		FindColumns: []string{},
+	}
+	j := 0
+	for _, pk := range d.PrimaryKeys {
+		if pk.ColumnName == "user_id" {
+			m.FindColumns = append([]string{pk.ColumnName}, m.FindColumns...)
+			continue
+		}
+		m.FindColumns = append(m.FindColumns, pk.ColumnName)
+		var named code
+		if pk.NamedType != "" {
+			named = qual(entityPackage, pk.NamedType)
+		}
+		m.Args = append(m.Args, i(fmt.Sprintf("k%d", j)).Add(valueTypeCode(pk.FieldType, named)))
+		j++
+	}
+	return m
+}
+
+// generateKeyLookupCode defines Save and Delete of a table whose primary key is not auto increment.
+// Save cannot rely on the assigned key, so it looks up the record to decide between insert and update.
+func (d *Dao) generateKeyLookupCode(f *file, cfg *Config, structName string) {
+	errorsLib := cfg.Libraries.Errors
+	entityPackage := cfg.importPath(cfg.Entity)
+	entityParam := i("e").Add(ptr(qual(entityPackage, d.Name)))
+	tableName := i("d").Dot("tableName")
+	tx := i("tx")
+	txGetterCall := list(i("tx"), i("err")).Op(":=").Id("d").Dot("txGetter").Call()
+	returnNil := rtn().Nil()
+	returnErr := rtn(traceErr(errorsLib))
+
+	pkQueryBuilder := i("b").Op(":=").Id("d").Dot("qb").Call()
+	isPrimaryKey := map[string]struct{}{}
+	for _, pk := range d.PrimaryKeys {
+		pkQueryBuilder.Dot("Eq").Call(lit(pk.ColumnName), pk.value(i("e").Dot(pk.Name)))
+		isPrimaryKey[pk.ColumnName] = struct{}{}
+	}
+
+	// Save
+	var userIDSetter code
+	if !d.IsReadOnly && strings.HasPrefix(d.TableName, "user_") {
+		userIDSetter = i("e").Dot("UserID").Op("=").Id("d").Dot("userIDGetter").Call()
+	}
+	m := cmap{}
+	for _, field := range d.Fields {
+		if _, exists := isPrimaryKey[field.ColumnName]; exists {
+			continue
+		}
+		if field.ColumnName == "created_at" || field.ColumnName == "user_id" || field.IsGenerated {
+			continue
+		}
+		m[lit(field.ColumnName)] = field.columnValue(entityPackage, i("e").Dot(field.Name))
+	}
+	pk := d.PrimaryKeys[0]
+	saveCodes := []code{
+		txGetterCall,
+		ifErr().Block(returnErr),
+		userIDSetter,
+		i("now").Op(":=").Qual("time", "Now").Call(),
+		i("e").Dot("UpdatedAt").Op("=").Add(d.field("updated_at").nowValue()),
+		pkQueryBuilder,
+		i("current").Op(":=").Add(addr(qual(entityPackage, d.Name))).Values(),
+		ifxErr(tx.Clone().Dot("FindByQueryBuilder").Call(i("b"), i("current"))).Block(
+			returnErr,
+		),
+		ifa(i("current").Dot(pk.Name), "==", pk.FieldType.zeroValue()).Block(
+			i("e").Dot("CreatedAt").Op("=").Add(d.field("created_at").nowValue()),
+			ifx(list(op("_"), i("err")).Op(":=").Add(tx.Clone().Dot("CreateByTable").Call(tableName, i("e"))), i("err"), "!=", null()).Block(
+				returnErr,
+			),
+			returnNil,
+		),
+	}
+	saveCodes = append(saveCodes, d.marshalCodes(errorsLib)...)
+	saveCodes = append(saveCodes,
+		i("m").Op(":=").Map(str()).Interface().Add(vals(m)),
+		ifxErr(tx.Clone().Dot("UpdateByQueryBuilder").Call(i("b"), i("m"))).Block(
+			returnErr,
+		),
+		returnNil,
+	)
+	f.Add(pfn("d", structName).Id("Save").Params(entityParam).Error().Block(saveCodes...)).Line()
+
+	// Delete
+	var emptyKey *statement
+	for _, pk := range d.PrimaryKeys {
+		cond := i("e").Dot(pk.Name).Op("==").Add(pk.FieldType.zeroValue())
+		if emptyKey == nil {
+			emptyKey = cond
+		} else {
+			emptyKey = emptyKey.Op("||").Add(cond)
+		}
+	}
+	f.Add(pfn("d", structName).Id("Delete").Params(entityParam).Error().Block(
+		txGetterCall,
+		ifErr().Block(returnErr),
+		ifb(emptyKey).Block(
+			rtn(qual(errorsLib, "New").Call(lit("cannot delete without identifier"))),
+		),
+		pkQueryBuilder.Clone(),
+		ifxErr(tx.Clone().Dot("DeleteByQueryBuilder").Call(i("b"))).Block(
+			returnErr,
+		),
+		returnNil,
+	)).Line()
+}
@@ -0,0 +1,81 @@
+package remodel
+
+import (
+	"strings"
+
+	"github.com/iancoleman/strcase"
+)
+
+// isDecimal reports whether the column is DECIMAL or NUMERIC, which is held in a string unless a decimal type is given.
+func (c *Column) isDecimal() bool {
+	return c.ColumnType == Decimal || c.ColumnType == Numeric
+}
+
+// decimalType returns the type of the decimal column, go_type of the column takes priority over decimal of the project.
+// Keys stay strings so that they are given to rapidash as they are.
+// A nullable column keeps NULL only as nil of a pointer, so it stays a nullable string unless the type is a pointer.
+func (c *Column) decimalType() string {
+	if !c.isDecimal() || c.IsPrimaryKey {
+		return ""
+	}
+	if c.GoType != "" {
+		return c.GoType
+	}
+	if !c.IsNotNull && !strings.HasPrefix(c.Decimal, "*") {
+		return ""
+	}
+	return c.Decimal
+}
+
+// fieldGoType returns the type of the field which is converted from the value of rapidash, empty for the others.
+func (c *Column) fieldGoType() string {
+	if c.EntityType == RawJSON {
+		return c.GoType
+	}
+	return c.decimalType()
+}
+
+// marshalTextCode marshals the field of the decimal type to the text given to rapidash.
+// A nil pointer is a nil *string, which rapidash writes as NULL.
+func marshalTextCode(errorsLib, fieldName, goType string) []code {
+	name := textName(fieldName)
+	if !strings.HasPrefix(goType, "*") {
+		return []code{
+			list(i(name), i("err")).Op(":=").Id("e").Dot(fieldName).Dot("MarshalText").Call(),
+			ifErr().Block(rtn(traceErr(errorsLib))),
+		}
+	}
+	return []code{
+		jvar(name).Add(ptr(str())),
+		ifa(i("e").Dot(fieldName), "!=", null()).Block(
+			list(i("b"), i("err")).Op(":=").Id("e").Dot(fieldName).Dot("MarshalText").Call(),
+			ifErr().Block(rtn(traceErr(errorsLib))),
+			i("s").Op(":=").Add(str().Call(i("b"))),
+			i(name).Op("=").Add(addr(i("s"))),
+		),
+	}
+}
+
+// unmarshalTextCode unmarshals the text of the column into the field of the decimal type.
+// NULL leaves the field as it is, a pointer is allocated only for a value.
+func unmarshalTextCode(errorsLib, fieldName, columnName, goType string) code {
+	if !strings.HasPrefix(goType, "*") {
+		return ifxBool(i("s").Op(":=").Id("dec").Dot("String").Call(lit(columnName)), i("s").Op("!=").Lit("")).Block(
+			ifxErr(i("e").Dot(fieldName).Dot("UnmarshalText").Call(idx().Byte().Call(i("s")))).Block(
+				rtn(traceErr(errorsLib)),
+			),
+		)
+	}
+	return ifxBool(i("s").Op(":=").Id("dec").Dot("StringPtr").Call(lit(columnName)), i("s").Op("!=").Nil()).Block(
+		i("v").Op(":=").Add(jnew(goTypeCode(strings.TrimPrefix(goType, "*"), ""))),
+		ifxErr(i("v").Dot("UnmarshalText").Call(idx().Byte().Call(ptr(i("s"))))).Block(
+			rtn(traceErr(errorsLib)),
+		),
+		i("e").Dot(fieldName).Op("=").Id("v"),
+	)
+}
+
+// textName returns the variable which holds the field of the decimal type marshalled to text.
+func textName(fieldName string) string {
+	return strcase.ToLowerCamel(fieldName) + "Text"
+}
@@ -0,0 +1,154 @@
+package remodel
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/juju/errors"
+	"github.com/xwb1989/sqlparser"
+)
+
+// Severity tells whether a diagnostic stops the generation.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Diagnostic is a problem found in ddl, located by the file, the table, the column and the position as far as they are known.
+type Diagnostic struct {
+	Severity Severity
+	Path     string
+	// Line and Col are 1-origin position in the file, 0 when unknown.
+	Line   int
+	Col    int
+	Table  string
+	Column string
+	// Rule is the lint rule which reports the diagnostic, empty for the parser.
+	Rule LintRule
+	Err  error
+
+	// offset is the byte offset in the statement found by the parser, 0 when unknown.
+	offset int
+}
+
+// Diagnostics are every problem found in a statement instead of the first one.
+type Diagnostics []*Diagnostic
+
+var syntaxErrorRegexp = regexp.MustCompile(`at position (\d+)(?: near '(.*)')?$`)
+
+func (d *Diagnostic) Error() string {
+	var b strings.Builder
+	if d.Path != "" {
+		b.WriteString(d.Path)
+		b.WriteString(":")
+	}
+	if d.Line > 0 {
+		fmt.Fprintf(&b, "%d:%d:", d.Line, d.Col)
+	}
+	if b.Len() > 0 {
+		b.WriteString(" ")
+	}
+	fmt.Fprintf(&b, "%s: ", d.Severity)
+	switch {
+	case d.Table != "" && d.Column != "":
+		fmt.Fprintf(&b, "%s.%s: ", d.Table, d.Column)
+	case d.Table != "":
+		fmt.Fprintf(&b, "%s: ", d.Table)
+	}
+	b.WriteString(d.Err.Error())
+	if d.Rule != "" {
+		fmt.Fprintf(&b, " (%s)", d.Rule)
+	}
+	return b.String()
+}
+
+func (s Diagnostics) Error() string {
+	msgs := make([]string, 0, len(s))
+	for _, d := range s {
+		msgs = append(msgs, d.Error())
+	}
+	return strings.Join(msgs, "\n")
+}
+
+func (s *Diagnostics) add(severity Severity, table, column string, err error) {
+	*s = append(*s, &Diagnostic{Severity: severity, Table: table, Column: column, Err: err})
+}
+
+// diagnostics returns the diagnostics in err, a plain error is an error of the table.
+func diagnostics(table string, err error) Diagnostics {
+	switch cause := errors.Cause(err).(type) {
+	case Diagnostics:
+		return cause
+	case *Diagnostic:
+		return Diagnostics{cause}
+	}
+	return Diagnostics{{Severity: SeverityError, Table: table, Err: err}}
+}
+
+// syntaxError makes a diagnostic of the error of sqlparser, which tells the position in parsed.
+// parsed is stmt without foreign keys, so the position is kept only when the text before it is the same.
+func syntaxError(table, stmt, parsed string, err error) *Diagnostic {
+	d := &Diagnostic{Severity: SeverityError, Table: table, Err: err}
+	m := syntaxErrorRegexp.FindStringSubmatch(err.Error())
+	if m == nil {
+		return d
+	}
+	pos, _ := strconv.Atoi(m[1])
+	// the position is after the token which the parser stopped at, and punctuations are not quoted as near
+	token := len(m[2])
+	if token == 0 {
+		token = 1
+	}
+	pos -= token + 1
+	if pos > 0 && pos <= len(parsed) && pos <= len(stmt) && parsed[:pos] == stmt[:pos] {
+		d.offset = pos
+	}
+	return d
+}
+
+// locate finds the position of the diagnostic in src, where stmt starts at start.
+// The column or the table is searched in stmt when the parser does not tell the position.
+func (d *Diagnostic) locate(src, stmt string, start int) {
+	// leading comments may have the names as well
+	begin := strings.Index(stmt, strings.TrimSpace(sqlparser.StripLeadingComments(stmt)))
+	if begin < 0 {
+		begin = 0
+	}
+	offset := d.offset
+	for _, ident := range []string{d.Column, d.Table} {
+		if offset > 0 || ident == "" {
+			continue
+		}
+		if idx := identIndex(stmt[begin:], ident); idx >= 0 {
+			offset = begin + idx
+		}
+	}
+	if offset <= 0 {
+		offset = begin
+	}
+	d.Line, d.Col = lineCol(src, start+offset)
+}
+
+// identIndex returns the offset of the first identifier name in stmt, -1 when it is not found.
+func identIndex(stmt, name string) int {
+	re := regexp.MustCompile("(?i)(?:^|[^\\w`])`?(" + regexp.QuoteMeta(name) + ")`?(?:$|[^\\w`])")
+	m := re.FindStringSubmatchIndex(stmt)
+	if m == nil {
+		return -1
+	}
+	return m[2]
+}
+
+// lineCol converts the byte offset in src to 1-origin line and column.
+func lineCol(src string, offset int) (int, int) {
+	if offset > len(src) {
+		offset = len(src)
+	}
+	before := src[:offset]
+	line := strings.Count(before, "\n") + 1
+	return line, offset - strings.LastIndex(before, "\n")
+}
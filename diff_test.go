@@ -0,0 +1,39 @@
+package remodel
+
+import (
+	"testing"
+
+	"github.com/yuki-eto/remodel/assert"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	t.Run("same", func(t *testing.T) {
+		assert.Equals(t, unifiedDiff("a.go", []byte("a\nb\n"), []byte("a\nb\n")), "")
+	})
+
+	t.Run("new_file", func(t *testing.T) {
+		diff := unifiedDiff("a.go", nil, []byte("a\nb\n"))
+		assert.Equals(t, diff, "--- a/a.go\n+++ b/a.go\n@@ -0,0 +1,2 @@\n+a\n+b\n")
+	})
+
+	t.Run("modify", func(t *testing.T) {
+		before := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n"
+		after := "1\n2\n3\n4\n5\nsix\n7\n8\n9\n10\n"
+		diff := unifiedDiff("a.go", []byte(before), []byte(after))
+		assert.Equals(t, diff, "--- a/a.go\n+++ b/a.go\n@@ -3,7 +3,7 @@\n 3\n 4\n 5\n-6\n+six\n 7\n 8\n 9\n")
+	})
+
+	t.Run("separated_hunks", func(t *testing.T) {
+		before := "a\n1\n2\n3\n4\n5\n6\n7\n8\nb\n"
+		after := "A\n1\n2\n3\n4\n5\n6\n7\n8\nB\n"
+		diff := unifiedDiff("a.go", []byte(before), []byte(after))
+		assert.Equals(t, diff, "--- a/a.go\n+++ b/a.go\n@@ -1,4 +1,4 @@\n-a\n+A\n 1\n 2\n 3\n@@ -7,4 +7,4 @@\n 6\n 7\n 8\n-b\n+B\n")
+	})
+
+	t.Run("no_newline_at_end", func(t *testing.T) {
+		diff := unifiedDiff("a.go", []byte("a\nb"), []byte("a\nb\n"))
+		assert.Equals(t, diff, "--- a/a.go\n+++ b/a.go\n@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+b\n")
+		diff = unifiedDiff("a.go", []byte("a\n"), []byte("a"))
+		assert.Equals(t, diff, "--- a/a.go\n+++ b/a.go\n@@ -1 +1 @@\n-a\n+a\n\\ No newline at end of file\n")
+	})
+}
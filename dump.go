@@ -0,0 +1,188 @@
+package remodel
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	versionedCommentRegexp = regexp.MustCompile(`(?s)/\*!\d*.*?\*/`)
+	fractionalNowRegexp    = regexp.MustCompile(`(?i)\bcurrent_timestamp(\s*\(\s*\d*\s*\))`)
+	sridRegexp             = regexp.MustCompile(`(?i)\bsrid\s+\d+`)
+	expressionDefaultRegex = regexp.MustCompile(`(?i)\bdefault\s*\(`)
+	keyPartOrderRegexp     = regexp.MustCompile(`(?i)\s+(?:asc|desc)\b`)
+	generatedColumnRegexp  = regexp.MustCompile(`(?i)\s(?:generated\s+always\s+)?as\s*\(`)
+	generatedStorageRegexp = regexp.MustCompile(`(?i)^\s*(?:virtual|stored)\b`)
+
+	// definitions which do not change the model, FOREIGN KEY is taken by stripForeignKeys
+	ignoredDefinitionRegexp = regexp.MustCompile("(?is)^(?:(?:fulltext|spatial)\\b|(?:constraint(?:\\s+[`\\w]+)?\\s+)?check\\b)")
+	indexDefinitionRegexp   = regexp.MustCompile("(?is)^(?:constraint(?:\\s+[`\\w]+)?\\s+)?(?:primary\\s+key|unique|key|index)\\b[^(]*?(\\s+using\\s+\\w+)?\\s*\\(")
+
+	tableCharsetRegexp   = regexp.MustCompile(`(?i)\b(?:charset|character\s+set)\s*=?\s*(\w+)`)
+	tableCollationRegexp = regexp.MustCompile(`(?i)\bcollate\s*=?\s*(\w+)`)
+)
+
+// normalizeDump blanks out what SHOW CREATE TABLE and mysqldump print but sqlparser cannot parse:
+// versioned comments, fractional seconds of CURRENT_TIMESTAMP, SRID, expression defaults,
+// FULLTEXT, SPATIAL and CHECK definitions, USING before key parts and the order of key parts.
+// They are replaced with spaces, so the positions of syntax errors still point to stmt.
+// The expressions of generated columns are blanked as well, and the names of the columns are returned.
+func normalizeDump(stmt string) (string, map[string]struct{}) {
+	b := []byte(stmt)
+	for _, m := range versionedCommentRegexp.FindAllStringIndex(stmt, -1) {
+		blank(b, m[0], m[1])
+	}
+	for _, m := range fractionalNowRegexp.FindAllStringSubmatchIndex(string(b), -1) {
+		blank(b, m[2], m[3])
+	}
+	for _, m := range sridRegexp.FindAllStringIndex(string(b), -1) {
+		blank(b, m[0], m[1])
+	}
+
+	s := string(b)
+	open := strings.Index(s, "(")
+	if open < 0 {
+		return s, nil
+	}
+	end := closingParen(s, open)
+	if end < 0 {
+		return s, nil
+	}
+	generated := map[string]struct{}{}
+	prev := open
+	for _, r := range specRanges(s, open+1, end) {
+		def := blankLiterals(s[r[0]:r[1]])
+		switch {
+		case ignoredDefinitionRegexp.MatchString(def):
+			if prev > open {
+				// the comma before the definition goes together
+				blank(b, prev, r[1])
+				continue
+			}
+			// nothing is kept before the first definition, so the comma after it goes instead
+			to := r[1]
+			if to < end {
+				to++
+			}
+			blank(b, r[0], to)
+			continue
+		case indexDefinitionRegexp.MatchString(def):
+			m := indexDefinitionRegexp.FindStringSubmatchIndex(def)
+			if m[2] >= 0 {
+				blank(b, r[0]+m[2], r[0]+m[3])
+			}
+			partsOpen := r[0] + m[1] - 1
+			if partsEnd := closingParen(s, partsOpen); partsEnd > 0 {
+				for _, o := range keyPartOrderRegexp.FindAllStringIndex(s[partsOpen:partsEnd], -1) {
+					blank(b, partsOpen+o[0], partsOpen+o[1])
+				}
+			}
+		case generatedColumnRegexp.MatchString(def):
+			m := generatedColumnRegexp.FindStringIndex(def)
+			exprEnd := closingParen(s, r[0]+m[1]-1)
+			if exprEnd < 0 {
+				break
+			}
+			if sm := generatedStorageRegexp.FindStringIndex(s[exprEnd+1 : r[1]]); sm != nil {
+				exprEnd += sm[1]
+			}
+			blank(b, r[0]+m[0], exprEnd+1)
+			generated[unquoteIdent(strings.Fields(def)[0])] = struct{}{}
+		default:
+			if m := expressionDefaultRegex.FindStringIndex(def); m != nil {
+				if exprEnd := closingParen(s, r[0]+m[1]-1); exprEnd > 0 {
+					blank(b, r[0]+m[0], exprEnd+1)
+				}
+			}
+		}
+		prev = r[1]
+	}
+	return string(b), generated
+}
+
+// blank replaces b[start:end] with spaces except newlines.
+func blank(b []byte, start, end int) {
+	for i := start; i < end; i++ {
+		if b[i] != '\n' {
+			b[i] = ' '
+		}
+	}
+}
+
+// blankLiterals replaces the contents of the string literals in def with spaces, so keywords in comments are not matched.
+func blankLiterals(def string) string {
+	b := []byte(def)
+	var quote byte
+	for i := 0; i < len(b); i++ {
+		c := b[i]
+		switch {
+		case quote != 0 && c == '\\' && i+1 < len(b):
+			blank(b, i, i+2)
+			i++
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote != 0:
+			blank(b, i, i+1)
+		case c == '\'' || c == '"':
+			quote = c
+		}
+	}
+	return string(b)
+}
+
+// specRanges returns the ranges of the definitions split by commas outside of parentheses and quotes in s[start:end].
+// A range starts after the comma and ends before the next one, so blanking it keeps the rest as is.
+func specRanges(s string, start, end int) [][2]int {
+	var (
+		ranges [][2]int
+		depth  int
+		quote  byte
+		begin  = start
+	)
+	trim := func(from, to int) {
+		for from < to && isSpace(s[from]) {
+			from++
+		}
+		if from < to {
+			ranges = append(ranges, [2]int{from, to})
+		}
+	}
+	for i := start; i < end; i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote != '`' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == ',' && depth == 0:
+			trim(begin, i)
+			begin = i + 1
+		}
+	}
+	trim(begin, end)
+	return ranges
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n'
+}
+
+// tableOption returns the charset or the collation in the table options.
+func tableOption(options string, re *regexp.Regexp) string {
+	// COMMENT may have the words as well
+	if m := tableCommentRegexp.FindStringIndex(options); m != nil {
+		options = options[:m[0]] + options[m[1]:]
+	}
+	if m := re.FindStringSubmatch(options); m != nil {
+		return m[1]
+	}
+	return ""
+}
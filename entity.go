@@ -1,12 +1,12 @@
 package remodel
 
 import (
+	"bytes"
 	"fmt"
 	"io"
-	"log"
-	"os"
 	"path/filepath"
 	"strings"
+	"unicode"
 
 	"github.com/gertd/go-pluralize"
 	"github.com/iancoleman/strcase"
@@ -15,11 +15,28 @@ import (
 
 type EntityType string
 
+// zeroValue returns the zero value of the type to check whether a key is set.
+func (t EntityType) zeroValue() code {
+	switch t {
+	case String:
+		return lit("")
+	case Bool:
+		return lit(false)
+	case TimePtr, ByteSlice, StringSlice, RawJSON:
+		return null()
+	case TimeValue:
+		return qual("time", "Time").Values()
+	}
+	return lit(0)
+}
+
 type Entity struct {
 	Name          string
+	Comment       string
 	SliceName     string
 	TableName     string
 	Fields        []*Field
+	PrimaryKeys   []*Field
 	IsReadOnly    bool
 	EntityPackage string
 }
@@ -27,43 +44,112 @@ type Entity struct {
 type Entities []*Entity
 
 type Field struct {
-	Name       string
-	ColumnName string
-	FieldType  EntityType
+	Name            string
+	ColumnName      string
+	FieldType       EntityType
+	Null            NullableMode
+	GoType          string
+	IsAutoIncrement bool
+	IsGenerated     bool
+	NamedType       string
+	EnumValues      []string
+	IsSet           bool
+	Comment         string
 }
 
-func (s *Entities) Output(rootPath string, isProtoc, isJSON bool) error {
-	for _, e := range *s {
-		if err := e.output(rootPath, isJSON); err != nil {
-			return errors.Trace(err)
-		}
-		if !isProtoc || e.IsReadOnly {
-			continue
-		}
-		if err := e.outputProtoBuf(rootPath); err != nil {
-			return errors.Trace(err)
+// enumTypeName returns the name of the type defined for an enum or set column, e.g. ItemRarity.
+func enumTypeName(entityName, fieldName string) string {
+	return entityName + fieldName
+}
+
+// enumConstName returns the name of the constant of an enum value or a set member, e.g. ItemRaritySSR.
+func enumConstName(typeName, value string) string {
+	name := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
 		}
+		return -1
+	}, strcase.ToCamel(value))
+	if name == "" {
+		name = "Empty"
 	}
+	return typeName + name
+}
 
-	structablePath := filepath.Join(rootPath, "entity", "structable.go")
-	out, err := os.Create(structablePath)
+// coderName returns the method name of rapidash.Encoder and rapidash.Decoder for the field.
+// Nullable fields use the methods for pointers like Int32Ptr.
+func (f *Field) coderName() string {
+	var name string
+	switch f.FieldType {
+	case TimePtr:
+		return "TimePtr"
+	case TimeValue:
+		name = "Time"
+	case ByteSlice, RawJSON:
+		return "Bytes"
+	case StringSlice:
+		return "Strings"
+	default:
+		name = strcase.ToCamel(string(f.FieldType))
+	}
+	// nil of a pointer to a decimal type is NULL
+	if f.Null != NullableNone || strings.HasPrefix(f.GoType, "*") {
+		name += "Ptr"
+	}
+	return name
+}
+
+// named returns the type defined for an enum or a set column, nil for the others.
+func (f *Field) named() code {
+	if f.NamedType == "" {
+		return nil
+	}
+	return i(f.NamedType)
+}
+
+func (s *Entities) Output(cfg *Config) error {
+	files, errs := renderParallel(cfg, len(*s), func(i int) ([]*generatedFile, GenerateErrors) {
+		e := (*s)[i]
+		if !cfg.matchTable(e.TableName) {
+			return nil, nil
+		}
+		return e.render(cfg)
+	})
+	// structable is always made from the whole table set
+	structable, err := s.renderStructable(cfg)
 	if err != nil {
-		return errors.Trace(err)
+		errs.add("", LayerEntity, err)
+	} else {
+		files = append(files, structable)
 	}
-	if _, err := out.WriteString("// Code generated by generate_code script - DO NOT EDIT.\n"); err != nil {
-		return errors.Trace(err)
+
+	errs = append(errs, writeGenerated(cfg, files)...)
+	return errs.orNil()
+}
+
+func structablePath(cfg *Config) (string, error) {
+	dir, err := cfg.layerDir(cfg.Entity)
+	if err != nil {
+		return "", errors.Trace(err)
 	}
-	if err := s.generateStructableCode(out); err != nil {
-		return errors.Trace(err)
+	return filepath.Join(dir, "structable.go"), nil
+}
+
+func (s *Entities) renderStructable(cfg *Config) (*generatedFile, error) {
+	structablePath, err := structablePath(cfg)
+	if err != nil {
+		return nil, errors.Trace(err)
 	}
-	if err := out.Close(); err != nil {
-		return errors.Trace(err)
+	out := &bytes.Buffer{}
+	out.WriteString(generatedCodeHeader)
+	if err := s.generateStructableCode(out, cfg); err != nil {
+		return nil, errors.Trace(err)
 	}
-	if err := applyGoimports(structablePath); err != nil {
-		return errors.Trace(err)
+	b, err := applyGoimports(structablePath, out.Bytes())
+	if err != nil {
+		return nil, errors.Trace(err)
 	}
-	log.Printf("output: %s", structablePath)
-	return nil
+	return &generatedFile{layer: LayerEntity, path: structablePath, body: b}, nil
 }
 
 func (e *Entity) fromTable(t *Table) {
@@ -71,6 +157,7 @@ func (e *Entity) fromTable(t *Table) {
 	e.Name = strcase.ToCamel(p.Singular(t.Name))
 	e.SliceName = strcase.ToCamel(t.Name)
 	e.TableName = t.Name
+	e.Comment = t.Comment
 	e.IsReadOnly = t.IsReadOnly
 
 	for _, c := range t.Columns {
@@ -80,155 +167,232 @@ func (e *Entity) fromTable(t *Table) {
 			fieldName = fieldName[:l-2] + "ID"
 		}
 		f := &Field{
-			Name:       fieldName,
-			ColumnName: c.Name,
-			FieldType:  c.EntityType,
+			Name:            fieldName,
+			ColumnName:      c.Name,
+			FieldType:       c.goType(),
+			Null:            c.nullMode(),
+			IsAutoIncrement: c.IsAutoIncrement,
+			IsGenerated:     c.IsGenerated,
+			Comment:         c.Comment,
+		}
+		f.GoType = c.fieldGoType()
+		if len(c.EnumValues) > 0 {
+			f.NamedType = enumTypeName(e.Name, fieldName)
+			f.EnumValues = c.EnumValues
+			f.IsSet = c.ColumnType == Set
 		}
 		e.Fields = append(e.Fields, f)
 	}
+	for _, c := range t.primaryKeyColumns() {
+		for _, f := range e.Fields {
+			if f.ColumnName == c.Name {
+				e.PrimaryKeys = append(e.PrimaryKeys, f)
+			}
+		}
+	}
 }
 
-func (e *Entity) output(rootPath string, isJSON bool) error {
-	entityPath, err := filepath.Abs(filepath.Join(rootPath, "entity", fmt.Sprintf("%s.go", strcase.ToSnake(e.Name))))
-	if err != nil {
-		return errors.Trace(err)
-	}
-	out, err := os.Create(entityPath)
+// render makes the entity code and the protocol buffers schema if necessary.
+func (e *Entity) render(cfg *Config) ([]*generatedFile, GenerateErrors) {
+	var (
+		files []*generatedFile
+		errs  GenerateErrors
+	)
+	f, err := e.renderCode(cfg)
 	if err != nil {
-		return errors.Trace(err)
-	}
-	if _, err := out.WriteString("// Code generated by generate_code script - DO NOT EDIT.\n"); err != nil {
-		return errors.Trace(err)
+		errs.add(e.TableName, LayerEntity, err)
+	} else {
+		files = append(files, f)
 	}
-	if err := e.generateCode(out, isJSON); err != nil {
-		return errors.Trace(err)
+	if !cfg.Proto || e.IsReadOnly {
+		return files, errs
 	}
-	if err := out.Close(); err != nil {
-		return errors.Trace(err)
+	f, err = e.renderProtoBuf(cfg)
+	if err != nil {
+		errs.add(e.TableName, LayerProto, err)
+	} else {
+		files = append(files, f)
 	}
+	return files, errs
+}
 
-	if err := applyGoimports(entityPath); err != nil {
-		return errors.Trace(err)
+func (e *Entity) codePath(cfg *Config) (string, error) {
+	dir, err := cfg.layerDir(cfg.Entity)
+	if err != nil {
+		return "", errors.Trace(err)
 	}
+	return filepath.Join(dir, fmt.Sprintf("%s.go", strcase.ToSnake(e.Name))), nil
+}
 
-	log.Printf("output: %s", entityPath)
-	return nil
+func (e *Entity) protoPath(cfg *Config) (string, error) {
+	dir, err := protoDir(cfg)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	return filepath.Join(dir, fmt.Sprintf("%s_entity.proto", strcase.ToSnake(e.Name))), nil
 }
 
-func (e *Entity) outputProtoBuf(rootPath string) error {
-	protoDir, err := filepath.Abs(filepath.Join(rootPath, "schema", "protobuf"))
+func (e *Entity) renderCode(cfg *Config) (*generatedFile, error) {
+	entityPath, err := e.codePath(cfg)
 	if err != nil {
-		return errors.Trace(err)
+		return nil, errors.Trace(err)
 	}
-	if _, err := os.Stat(protoDir); err != nil {
-		if err := os.MkdirAll(protoDir, 0755); err != nil {
-			return errors.Trace(err)
-		}
-		log.Printf("make directory: %s", protoDir)
+	out := &bytes.Buffer{}
+	out.WriteString(generatedCodeHeader)
+	if err := e.generateCode(out, cfg); err != nil {
+		return nil, errors.Trace(err)
 	}
 
-	protoPath := filepath.Join(protoDir, fmt.Sprintf("%s_entity.proto", strcase.ToSnake(e.Name)))
-	out, err := os.Create(protoPath)
+	b, err := applyGoimports(entityPath, out.Bytes())
 	if err != nil {
-		return errors.Trace(err)
+		return nil, errors.Trace(err)
 	}
-	if err := e.generateProtocolBuffers(out); err != nil {
-		return errors.Trace(err)
+
+	return &generatedFile{table: e.TableName, layer: LayerEntity, path: entityPath, body: b}, nil
+}
+
+func protoDir(cfg *Config) (string, error) {
+	dir, err := filepath.Abs(filepath.Join(cfg.RootDir, "schema", "protobuf"))
+	return dir, errors.Trace(err)
+}
+
+func (e *Entity) renderProtoBuf(cfg *Config) (*generatedFile, error) {
+	protoPath, err := e.protoPath(cfg)
+	if err != nil {
+		return nil, errors.Trace(err)
 	}
-	if err := out.Close(); err != nil {
-		return errors.Trace(err)
+
+	out := &bytes.Buffer{}
+	out.WriteString(generatedCodeHeader)
+	if err := e.generateProtocolBuffers(out); err != nil {
+		return nil, errors.Trace(err)
 	}
-	log.Printf("output: %s", protoPath)
 
-	return nil
+	return &generatedFile{table: e.TableName, layer: LayerProto, path: protoPath, body: out.Bytes()}, nil
 }
 
-func (e *Entity) generateCode(writer io.Writer, isJSON bool) error {
-	f := newFile("entity")
-	f.ImportName(ErrorsLib, "errors")
-	f.ImportName(RapidashLib, "rapidash")
+func (e *Entity) generateCode(writer io.Writer, cfg *Config) error {
+	errorsLib := cfg.Libraries.Errors
+	rapidashLib := cfg.Libraries.Rapidash
+	f := newFile(cfg.Entity.Package)
+	f.ImportName(errorsLib, "errors")
+	f.ImportName(rapidashLib, "rapidash")
 	const jsonPackage = "encoding/json"
-	if isJSON {
+	if cfg.JSON {
 		f.ImportName(jsonPackage, "json")
 	}
 
 	var fields []code
 	for _, field := range e.Fields {
+		fields = append(fields, comments(field.Comment)...)
 		fields = append(fields, e.fieldToCode(field))
 	}
 	// define struct
+	for _, c := range comments(e.Comment) {
+		f.Add(c)
+	}
 	f.Type().Id(e.Name).Struct(fields...).Line()
 	f.Type().Id(e.SliceName).Index().Op("*").Id(e.Name).Line()
+	for _, field := range e.Fields {
+		var err error
+		if field.IsSet {
+			err = field.generateSetCode(f, errorsLib)
+		} else if field.NamedType != "" {
+			err = field.generateEnumCode(f, errorsLib)
+		}
+		if err != nil {
+			return errors.Trace(err)
+		}
+	}
 
-	var (
-		decodeCodes []code
-	)
-	encodeCodes := []code{
-		ifa(i("e").Dot("ID"), "!=", lit(0)).Block(
-			i("enc").Dot("Uint64").Call(lit("id"), i("e").Dot("ID")),
-		),
+	decodeCodes := []code{}
+	encodeCodes := []code{}
+	// only auto increment key can be left to the database, the others are always given by the caller
+	if len(e.PrimaryKeys) == 1 && e.PrimaryKeys[0].IsAutoIncrement {
+		pk := e.PrimaryKeys[0]
+		encodeCodes = append(encodeCodes, ifa(i("e").Dot(pk.Name), "!=", pk.FieldType.zeroValue()).Block(
+			i("enc").Dot(pk.coderName()).Call(lit(pk.ColumnName), i("e").Dot(pk.Name)),
+		))
 	}
 	structCodes := []code{
-		i("s").Op(":=").Qual(RapidashLib, "NewStruct").Call(lit(e.TableName)),
+		i("s").Op(":=").Qual(rapidashLib, "NewStruct").Call(lit(e.TableName)),
 	}
 	for _, field := range e.Fields {
 		fieldName := field.Name
 		decodeCode := i("e").Dot(fieldName).Op("=")
-		fieldType := strcase.ToCamel(string(field.FieldType))
-		structType := fieldType
-		if field.FieldType == TimePtr {
-			fieldType = "TimePtr"
-			structType = "Time"
-		} else if field.FieldType == ByteSlice {
-			fieldType = "Bytes"
-			structType = "Bytes"
-		} else if field.FieldType == StringSlice {
-			fieldType = "Strings"
-			structType = "Strings"
-		}
+		fieldType := field.coderName()
+		structType := strings.TrimSuffix(fieldType, "Ptr")
 		structCode := i("s").Dot("Field" + structType).Call(lit(field.ColumnName))
 		if structType == "Strings" {
-			structCode = i("s").Dot("FieldSlice").Call(lit(field.ColumnName), qual(RapidashLib, "StringType"))
+			structCode = i("s").Dot("FieldSlice").Call(lit(field.ColumnName), qual(rapidashLib, "StringType"))
 		}
-		decodeCode.Id("dec").Dot(fieldType).Call(lit(field.ColumnName))
-		encodeCode := i("enc").Dot(fieldType).Call(lit(field.ColumnName), i("e").Dot(field.Name))
-		encodeCodes = append(encodeCodes, encodeCode)
-		decodeCodes = append(decodeCodes, decodeCode)
 		structCodes = append(structCodes, structCode)
+		if field.GoType != "" {
+			if !field.IsGenerated {
+				encodeCodes = append(encodeCodes, marshalCode(errorsLib, field.Name, field.GoType, field.FieldType)...)
+				encodeCodes = append(encodeCodes, i("enc").Dot(fieldType).Call(lit(field.ColumnName), marshalledValue(field.Name, field.GoType, field.FieldType)))
+			}
+			decodeCodes = append(decodeCodes, unmarshalCode(errorsLib, field.Name, field.ColumnName, field.GoType, field.FieldType))
+			continue
+		}
+		decodeValue := i("dec").Dot(fieldType).Call(lit(field.ColumnName))
+		encodeValue := i("e").Dot(field.Name)
+		switch {
+		case field.FieldType == RawJSON:
+			decodeValue = qual("encoding/json", "RawMessage").Call(decodeValue)
+			encodeValue = idx().Byte().Call(encodeValue)
+		case field.Null == NullableSQL:
+			// rapidash knows only pointers for NULL
+			name := sqlNullName(field.FieldType)
+			decodeValue = i("New" + name).Call(decodeValue)
+			encodeValue = i(name + "Ptr").Call(encodeValue)
+		case field.Null == NullablePointer && field.NamedType != "":
+			decodeValue = jparens(ptr(i(field.NamedType))).Call(decodeValue)
+			encodeValue = jparens(ptr(i(string(field.FieldType)))).Call(encodeValue)
+		case field.NamedType != "":
+			decodeValue = i(field.NamedType).Call(decodeValue)
+			encodeValue = i(string(field.FieldType)).Call(encodeValue)
+		}
+		decodeCode.Add(decodeValue)
+		decodeCodes = append(decodeCodes, decodeCode)
+		// MySQL rejects the value of a generated column on insert
+		if !field.IsGenerated {
+			encodeCodes = append(encodeCodes, i("enc").Dot(fieldType).Call(lit(field.ColumnName), encodeValue))
+		}
 	}
 	encodeCodes = append(encodeCodes, rtn(i("enc").Dot("Error").Call()))
 	decodeCodes = append(decodeCodes, rtn(i("dec").Dot("Error").Call()))
 	structCodes = append(structCodes, rtn(i("s")))
 
 	if !e.IsReadOnly {
-		f.Add(pfn("e", e.Name).Id("EncodeRapidash").Params(i("enc").Qual(RapidashLib, "Encoder")).Error().Block(
+		f.Add(pfn("e", e.Name).Id("EncodeRapidash").Params(i("enc").Qual(rapidashLib, "Encoder")).Error().Block(
 			encodeCodes...,
 		)).Line()
-		f.Add(pfn("e", e.SliceName).Id("EncodeRapidash").Params(i("enc").Qual(RapidashLib, "Encoder")).Error()).Block(
+		f.Add(pfn("e", e.SliceName).Id("EncodeRapidash").Params(i("enc").Qual(rapidashLib, "Encoder")).Error()).Block(
 			forEachV("v", ptr(i("e"))).Block(
 				ifxErr(i("v").Dot("EncodeRapidash").Call(i("enc").Dot("New").Call())).Block(
-					rtn(traceErr()),
+					rtn(traceErr(errorsLib)),
 				),
 			),
 			rtn(null()),
 		).Line()
 	}
-	f.Add(pfn("e", e.Name).Id("DecodeRapidash").Params(i("dec").Qual(RapidashLib, "Decoder")).Error().Block(decodeCodes...)).Line()
-	f.Add(pfn("e", e.SliceName).Id("DecodeRapidash").Params(i("dec").Qual(RapidashLib, "Decoder")).Error().Block(
+	f.Add(pfn("e", e.Name).Id("DecodeRapidash").Params(i("dec").Qual(rapidashLib, "Decoder")).Error().Block(decodeCodes...)).Line()
+	f.Add(pfn("e", e.SliceName).Id("DecodeRapidash").Params(i("dec").Qual(rapidashLib, "Decoder")).Error().Block(
 		i("count").Op(":=").Id("dec").Dot("Len").Call(),
 		ptr(i("e")).Op("=").Make(idx().Add(ptr(i(e.Name))), i("count")),
 		forItr("i", lit(0), "<", i("count")).Block(
 			jvar("v").Id(e.Name),
 			ifxErr(i("v").Dot("DecodeRapidash").Call(i("dec").Dot("At").Call(i("i")))).Block(
-				rtn(traceErr()),
+				rtn(traceErr(errorsLib)),
 			),
 			op("(").Add(ptr(i("e"))).Op(")").Index(i("i")).Op("=").Add(addr(i("v"))),
 		),
 		rtn(null()),
 	)).Line()
-	f.Add(pfn("e", e.Name).Id("Struct").Params().Params(ptr().Add(qual(RapidashLib, "Struct"))).Block(structCodes...)).Line()
+	f.Add(pfn("e", e.Name).Id("Struct").Params().Params(ptr().Add(qual(rapidashLib, "Struct"))).Block(structCodes...)).Line()
 
-	if !isJSON {
+	if !cfg.JSON {
 		return errors.Trace(f.Render(writer))
 	}
 
@@ -242,14 +406,24 @@ func (e *Entity) generateCode(writer io.Writer, isJSON bool) error {
 		if e.TableName == "users" && f.ColumnName == "id" {
 			continue
 		}
-		if f.FieldType == TimePtr {
-			timePtrsCodes = append(timePtrsCodes, ifa(i("e").Dot(f.Name), "!=", null()).Block(
-				i("m").Index(lit(f.lowerCamelName())).Op("=").Id("e").Dot(f.Name).Dot("Unix").Call(),
+		if f.FieldType == TimePtr || f.FieldType == TimeValue && f.Null != NullableNone {
+			valid, value := nullableValue(i("e").Dot(f.Name), f.FieldType, f.Null, nil)
+			if f.Null != NullableSQL {
+				value = i("e").Dot(f.Name)
+			}
+			timePtrsCodes = append(timePtrsCodes, ifb(valid).Block(
+				i("m").Index(lit(f.lowerCamelName())).Op("=").Add(value).Dot("Unix").Call(),
 			))
 			continue
 		}
 		key := lit(f.lowerCamelName())
 		value := i("e").Dot(f.Name)
+		switch {
+		case f.FieldType == TimeValue:
+			value = value.Dot("Unix").Call()
+		case f.Null == NullableSQL:
+			value = i(sqlNullName(f.FieldType) + "Ptr").Call(value)
+		}
 		values[key] = value
 	}
 	codes := []code{
@@ -259,7 +433,7 @@ func (e *Entity) generateCode(writer io.Writer, isJSON bool) error {
 		codes = append(codes, timePtrsCodes...)
 	}
 	codes = append(codes, list(i("b"), i("err")).Op(":=").Qual(jsonPackage, "Marshal").Call(i("m")))
-	codes = append(codes, rtn(i("b"), traceErr()))
+	codes = append(codes, rtn(i("b"), traceErr(errorsLib)))
 
 	f.Add(pfn("e", e.Name).Id("MarshalJSON").Params().Params(idx().Byte(), jerr()).Block(codes...)).Line()
 
@@ -267,10 +441,108 @@ func (e *Entity) generateCode(writer io.Writer, isJSON bool) error {
 }
 
 func (f *Field) typeToCode() code {
-	if f.FieldType == TimePtr {
-		return ptr().Qual("time", "Time")
+	if f.GoType != "" {
+		return goTypeCode(f.GoType, "")
+	}
+	return nullableTypeCode(f.FieldType, f.Null, f.named())
+}
+
+// enumConsts returns the names of the constants of the enum values or the set members.
+func (f *Field) enumConsts() ([]code, error) {
+	var consts []code
+	names := map[string]string{}
+	for _, v := range f.EnumValues {
+		name := enumConstName(f.NamedType, v)
+		if other, exists := names[name]; exists {
+			return nil, errors.Errorf("values %q and %q of %s have the same constant name %s", other, v, f.ColumnName, name)
+		}
+		names[name] = v
+		consts = append(consts, i(name))
 	}
-	return i(string(f.FieldType))
+	return consts, nil
+}
+
+// generateEnumCode defines the type of an enum column with a constant per value.
+func (f *Field) generateEnumCode(jf *file, errorsLib string) error {
+	consts, err := f.enumConsts()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	var defs []code
+	for j, v := range f.EnumValues {
+		defs = append(defs, consts[j].(*statement).Clone().Id(f.NamedType).Op("=").Lit(v))
+	}
+	jf.Type().Id(f.NamedType).String().Line()
+	jf.Const().Defs(defs...).Line()
+	jf.Func().Params(i("t").Id(f.NamedType)).Id("String").Params().String().Block(
+		rtn(str().Call(i("t"))),
+	).Line()
+	jf.Func().Params(i("t").Id(f.NamedType)).Id("IsValid").Params().Bool().Block(
+		jswitch(i("t")).Block(
+			jcase(consts...).Block(rtn(bools(true))),
+		),
+		rtn(bools(false)),
+	).Line()
+	jf.Func().Id("Parse"+f.NamedType).Params(i("s").String()).Params(i(f.NamedType), jerr()).Block(
+		i("t").Op(":=").Id(f.NamedType).Call(i("s")),
+		ifb(op("!").Add(i("t").Dot("IsValid").Call())).Block(
+			rtn(lit(""), qual(errorsLib, "Errorf").Call(lit("invalid "+f.NamedType+": %s"), i("s"))),
+		),
+		rtn(i("t"), null()),
+	).Line()
+	return nil
+}
+
+// generateSetCode defines the type of a set column with a constant per member.
+// the members are kept as []string to be encoded by rapidash as it is.
+func (f *Field) generateSetCode(jf *file, errorsLib string) error {
+	consts, err := f.enumConsts()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	var defs []code
+	for j, v := range f.EnumValues {
+		defs = append(defs, consts[j].(*statement).Clone().Op("=").Lit(v))
+	}
+	isMember := jswitch(i("member")).Block(
+		jcase(consts...),
+		jdefault().Block(
+			rtn(qual(errorsLib, "Errorf").Call(lit("invalid member of "+f.NamedType+": %s"), i("member"))),
+		),
+	)
+	jf.Type().Id(f.NamedType).Index().String().Line()
+	jf.Const().Defs(defs...).Line()
+	jf.Func().Params(i("s").Id(f.NamedType)).Id("Has").Params(i("member").String()).Bool().Block(
+		forEachV("v", i("s")).Block(
+			ifa(i("v"), "==", i("member")).Block(rtn(bools(true))),
+		),
+		rtn(bools(false)),
+	).Line()
+	jf.Func().Params(i("s").Op("*").Id(f.NamedType)).Id("Add").Params(i("member").String()).Error().Block(
+		isMember,
+		ifb(op("!").Add(i("s").Dot("Has").Call(i("member")))).Block(
+			ptr(i("s")).Op("=").Append(ptr(i("s")), i("member")),
+		),
+		rtn(null()),
+	).Line()
+	jf.Func().Params(i("s").Op("*").Id(f.NamedType)).Id("Remove").Params(i("member").String()).Block(
+		forEach("i", "v", ptr(i("s"))).Block(
+			ifa(i("v"), "==", i("member")).Block(
+				ptr(i("s")).Op("=").Append(op("(").Add(ptr(i("s"))).Op(")").Index(op(":").Id("i")), op("(").Add(ptr(i("s"))).Op(")").Index(i("i").Op("+").Lit(1).Op(":")).Op("...")),
+				rtn(),
+			),
+		),
+	).Line()
+	jf.Func().Params(i("s").Id(f.NamedType)).Id("IsValid").Params().Bool().Block(
+		forEachV("v", i("s")).Block(
+			jswitch(i("v")).Block(
+				jcase(consts...),
+				jdefault().Block(rtn(bools(false))),
+			),
+		),
+		rtn(bools(true)),
+	).Line()
+	return nil
 }
 
 func (f *Field) toProtoBufType() string {
@@ -285,9 +557,9 @@ func (f *Field) toProtoBufType() string {
 		return "double"
 	case Float32:
 		return "float"
-	case TimePtr:
+	case TimePtr, TimeValue:
 		return "int64"
-	case ByteSlice:
+	case ByteSlice, RawJSON:
 		return "bytes"
 	case StringSlice:
 		return "repeated string"
@@ -319,15 +591,20 @@ func (e *Entity) fieldToGetMethodCode(f *Field) code {
 	)
 }
 
-func (s *Entities) generateStructableCode(writer io.Writer) error {
-	f := newFile("entity")
+func (s *Entities) generateStructableCode(writer io.Writer, cfg *Config) error {
+	rapidashLib := cfg.Libraries.Rapidash
+	f := newFile(cfg.Entity.Package)
 
-	f.ImportName(RapidashLib, "rapidash")
+	f.ImportName(rapidashLib, "rapidash")
 
 	f.Type().Id("Structable").Interface(
-		i("Struct").Call().Add(ptr(qual(RapidashLib, "Struct"))),
+		i("Struct").Call().Add(ptr(qual(rapidashLib, "Struct"))),
 	)
 
+	if cfg.Nullable == NullableSQL {
+		generateSQLNullCode(f)
+	}
+
 	tables := cmap{}
 	roTables := cmap{}
 	for _, v := range *s {
@@ -348,6 +625,18 @@ func (s *Entities) generateStructableCode(writer io.Writer) error {
 	return errors.Trace(f.Render(writer))
 }
 
+// protoComments returns the lines of a comment in protocol buffers.
+func protoComments(indent, text string) []string {
+	if text == "" {
+		return nil
+	}
+	var lines []string
+	for _, l := range strings.Split(text, "\n") {
+		lines = append(lines, strings.TrimRight(indent+"// "+strings.TrimRight(l, "\r"), " "))
+	}
+	return lines
+}
+
 func (e *Entity) generateProtocolBuffers(writer io.Writer) error {
 	lines := []string{
 		`syntax = "proto3";`,
@@ -355,6 +644,7 @@ func (e *Entity) generateProtocolBuffers(writer io.Writer) error {
 		"",
 	}
 
+	lines = append(lines, protoComments("", e.Comment)...)
 	lines = append(lines, fmt.Sprintf("message %s {", e.Name+"Entity"))
 
 	i := 1
@@ -362,6 +652,7 @@ func (e *Entity) generateProtocolBuffers(writer io.Writer) error {
 		if (e.TableName == "users" && f.Name == "ID") || f.Name == "UserID" {
 			continue
 		}
+		lines = append(lines, protoComments("  ", f.Comment)...)
 		lines = append(lines, fmt.Sprintf("  %s %s = %d;", f.toProtoBufType(), f.ColumnName, i))
 		i++
 	}
@@ -1,6 +1,7 @@
 package dao
 
 import (
+	"example/entity"
 	"fmt"
 	"strings"
 	"testing"
@@ -61,7 +62,7 @@ func TestItemImpl(t *testing.T) {
 
 	t.Run("find_by_type", func(t *testing.T) {
 		d := NewItem(fn)
-		const typ = "consumable"
+		const typ = entity.ItemTypeConsumable
 		items, err := d.FindByType(typ)
 		if err != nil {
 			t.Fatal(err)
@@ -75,7 +76,7 @@ func TestItemImpl(t *testing.T) {
 
 	t.Run("find_by_rarity", func(t *testing.T) {
 		d := NewItem(fn)
-		const rarity = "SR"
+		const rarity = entity.ItemRaritySR
 		items, err := d.FindByRarity(rarity)
 		if err != nil {
 			t.Fatal(err)
@@ -9,19 +9,74 @@ import (
 )
 
 type Item struct {
-	ID       uint64 `csv:"Id"`
-	Type     string `csv:"Type"`
-	Rarity   string `csv:"Rarity"`
-	Name     string `csv:"Name"`
-	MaxCount uint16 `csv:"MaxCount"`
+	ID       uint64     `csv:"Id"`
+	Type     ItemType   `csv:"Type"`
+	Rarity   ItemRarity `csv:"Rarity"`
+	Name     string     `csv:"Name"`
+	MaxCount uint16     `csv:"MaxCount"`
 }
 
 type Items []*Item
 
+type ItemType string
+
+const (
+	ItemTypeConsumable ItemType = "consumable"
+	ItemTypeImportant  ItemType = "important"
+)
+
+func (t ItemType) String() string {
+	return string(t)
+}
+
+func (t ItemType) IsValid() bool {
+	switch t {
+	case ItemTypeConsumable, ItemTypeImportant:
+		return true
+	}
+	return false
+}
+
+func ParseItemType(s string) (ItemType, error) {
+	t := ItemType(s)
+	if !t.IsValid() {
+		return "", errors.Errorf("invalid ItemType: %s", s)
+	}
+	return t, nil
+}
+
+type ItemRarity string
+
+const (
+	ItemRarityR   ItemRarity = "R"
+	ItemRaritySR  ItemRarity = "SR"
+	ItemRaritySSR ItemRarity = "SSR"
+)
+
+func (t ItemRarity) String() string {
+	return string(t)
+}
+
+func (t ItemRarity) IsValid() bool {
+	switch t {
+	case ItemRarityR, ItemRaritySR, ItemRaritySSR:
+		return true
+	}
+	return false
+}
+
+func ParseItemRarity(s string) (ItemRarity, error) {
+	t := ItemRarity(s)
+	if !t.IsValid() {
+		return "", errors.Errorf("invalid ItemRarity: %s", s)
+	}
+	return t, nil
+}
+
 func (e *Item) DecodeRapidash(dec rapidash.Decoder) error {
 	e.ID = dec.Uint64("id")
-	e.Type = dec.String("type")
-	e.Rarity = dec.String("rarity")
+	e.Type = ItemType(dec.String("type"))
+	e.Rarity = ItemRarity(dec.String("rarity"))
 	e.Name = dec.String("name")
 	e.MaxCount = dec.Uint16("max_count")
 	return dec.Error()
@@ -13,21 +13,67 @@ type UserByte struct {
 	ID        uint64
 	UserID    uint64
 	Bytes     []byte
-	Tags      []string
+	Tags      UserByteTags
 	CreatedAt *time.Time
 	UpdatedAt *time.Time
 }
 
 type UserBytes []*UserByte
 
-func (e *UserByte) EncodeRapidash(enc rapidash.Encoder) error {
-	if e.ID != 0 {
-		enc.Uint64("id", e.ID)
+type UserByteTags []string
+
+const (
+	UserByteTagsOne   = "one"
+	UserByteTagsTwo   = "two"
+	UserByteTagsThree = "three"
+)
+
+func (s UserByteTags) Has(member string) bool {
+	for _, v := range s {
+		if v == member {
+			return true
+		}
 	}
+	return false
+}
+
+func (s *UserByteTags) Add(member string) error {
+	switch member {
+	case UserByteTagsOne, UserByteTagsTwo, UserByteTagsThree:
+	default:
+		return errors.Errorf("invalid member of UserByteTags: %s", member)
+	}
+	if !s.Has(member) {
+		*s = append(*s, member)
+	}
+	return nil
+}
+
+func (s *UserByteTags) Remove(member string) {
+	for i, v := range *s {
+		if v == member {
+			*s = append((*s)[:i], (*s)[i+1:]...)
+			return
+		}
+	}
+}
+
+func (s UserByteTags) IsValid() bool {
+	for _, v := range s {
+		switch v {
+		case UserByteTagsOne, UserByteTagsTwo, UserByteTagsThree:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func (e *UserByte) EncodeRapidash(enc rapidash.Encoder) error {
 	enc.Uint64("id", e.ID)
 	enc.Uint64("user_id", e.UserID)
 	enc.Bytes("bytes", e.Bytes)
-	enc.Strings("tags", e.Tags)
+	enc.Strings("tags", []string(e.Tags))
 	enc.TimePtr("created_at", e.CreatedAt)
 	enc.TimePtr("updated_at", e.UpdatedAt)
 	return enc.Error()
@@ -46,7 +92,7 @@ func (e *UserByte) DecodeRapidash(dec rapidash.Decoder) error {
 	e.ID = dec.Uint64("id")
 	e.UserID = dec.Uint64("user_id")
 	e.Bytes = dec.Bytes("bytes")
-	e.Tags = dec.Strings("tags")
+	e.Tags = UserByteTags(dec.Strings("tags"))
 	e.CreatedAt = dec.TimePtr("created_at")
 	e.UpdatedAt = dec.TimePtr("updated_at")
 	return dec.Error()
@@ -118,7 +118,7 @@ func (i *ItemsInstance) IDs() []uint64 {
 	return s
 }
 
-func (i *ItemsInstance) FilterByType(c string) *ItemsInstance {
+func (i *ItemsInstance) FilterByType(c entity.ItemType) *ItemsInstance {
 	s := NewItemsInstance()
 	for _, v := range i.values {
 		if v.Type == c {
@@ -140,15 +140,15 @@ func (i *ItemsInstance) SortByType(isDesc bool) *ItemsInstance {
 	return s
 }
 
-func (i *ItemsInstance) Types() []string {
-	s := []string{}
+func (i *ItemsInstance) Types() []entity.ItemType {
+	s := []entity.ItemType{}
 	i.Each(func(v *ItemInstance) {
 		s = append(s, v.Type)
 	})
 	return s
 }
 
-func (i *ItemsInstance) FilterByRarity(c string) *ItemsInstance {
+func (i *ItemsInstance) FilterByRarity(c entity.ItemRarity) *ItemsInstance {
 	s := NewItemsInstance()
 	for _, v := range i.values {
 		if v.Rarity == c {
@@ -170,8 +170,8 @@ func (i *ItemsInstance) SortByRarity(isDesc bool) *ItemsInstance {
 	return s
 }
 
-func (i *ItemsInstance) Rarities() []string {
-	s := []string{}
+func (i *ItemsInstance) Rarities() []entity.ItemRarity {
+	s := []entity.ItemRarity{}
 	i.Each(func(v *ItemInstance) {
 		s = append(s, v.Rarity)
 	})
@@ -42,6 +42,23 @@ func (i *UserByteInstance) Delete() error {
 	return errors.Trace(i.userByteDao.Delete(i.UserByte))
 }
 
+func (i *UserByteInstance) User(d dao.User) (*UserInstance, error) {
+	if i.UserID == 0 {
+		return nil, nil
+	}
+	e, err := d.FindByID(i.UserID)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if e == nil {
+		return nil, nil
+	}
+	return &UserInstance{
+		User:    e,
+		userDao: d,
+	}, nil
+}
+
 type UserBytesInstance struct {
 	values []*UserByteInstance
 }
@@ -200,7 +217,7 @@ func (i *UserBytesInstance) Bytes() [][]byte {
 	return s
 }
 
-func (i *UserBytesInstance) FilterByTags(c []string) *UserBytesInstance {
+func (i *UserBytesInstance) FilterByTags(c entity.UserByteTags) *UserBytesInstance {
 	s := NewUserBytesInstance()
 	sort.Strings(c)
 	cs := strings.Join(c, ",")
@@ -214,6 +231,16 @@ func (i *UserBytesInstance) FilterByTags(c []string) *UserBytesInstance {
 	return s
 }
 
+func (i *UserBytesInstance) FilterByTagsContaining(member string) *UserBytesInstance {
+	s := NewUserBytesInstance()
+	for _, v := range i.values {
+		if v.Tags.Has(member) {
+			s.Add(v)
+		}
+	}
+	return s
+}
+
 func (i *UserBytesInstance) SortByTags(isDesc bool) *UserBytesInstance {
 	s := NewUserBytesInstance()
 	s.values = i.values
@@ -226,8 +253,8 @@ func (i *UserBytesInstance) SortByTags(isDesc bool) *UserBytesInstance {
 	return s
 }
 
-func (i *UserBytesInstance) Tags() [][]string {
-	s := [][]string{}
+func (i *UserBytesInstance) Tags() []entity.UserByteTags {
+	s := []entity.UserByteTags{}
 	i.Each(func(v *UserByteInstance) {
 		s = append(s, v.Tags)
 	})
@@ -294,6 +321,33 @@ func (i *UserBytesInstance) UpdatedAts() []*time.Time {
 	return s
 }
 
+func (i *UserBytesInstance) Users(d dao.User) (*UsersInstance, error) {
+	keys := []uint64{}
+	found := map[uint64]struct{}{}
+	for _, v := range i.values {
+		if _, exists := found[v.UserID]; exists || v.UserID == 0 {
+			continue
+		}
+		found[v.UserID] = struct{}{}
+		keys = append(keys, v.UserID)
+	}
+	s := NewUsersInstance()
+	if len(keys) == 0 {
+		return s, nil
+	}
+	es, err := d.FindByIDs(keys)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	for _, e := range es {
+		s.Add(&UserInstance{
+			User:    e,
+			userDao: d,
+		})
+	}
+	return s, nil
+}
+
 func (i *UserBytesInstance) Save() error {
 	return i.EachWithError(func(i *UserByteInstance) error {
 		return errors.Trace(i.Save())
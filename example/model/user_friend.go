@@ -40,6 +40,40 @@ func (i *UserFriendInstance) Delete() error {
 	return errors.Trace(i.userFriendDao.Delete(i.UserFriend))
 }
 
+func (i *UserFriendInstance) User(d dao.User) (*UserInstance, error) {
+	if i.UserID == 0 {
+		return nil, nil
+	}
+	e, err := d.FindByID(i.UserID)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if e == nil {
+		return nil, nil
+	}
+	return &UserInstance{
+		User:    e,
+		userDao: d,
+	}, nil
+}
+
+func (i *UserFriendInstance) OtherUser(d dao.User) (*UserInstance, error) {
+	if i.OtherUserID == 0 {
+		return nil, nil
+	}
+	e, err := d.FindByID(i.OtherUserID)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if e == nil {
+		return nil, nil
+	}
+	return &UserInstance{
+		User:    e,
+		userDao: d,
+	}, nil
+}
+
 type UserFriendsInstance struct {
 	values []*UserFriendInstance
 }
@@ -258,6 +292,60 @@ func (i *UserFriendsInstance) UpdatedAts() []*time.Time {
 	return s
 }
 
+func (i *UserFriendsInstance) Users(d dao.User) (*UsersInstance, error) {
+	keys := []uint64{}
+	found := map[uint64]struct{}{}
+	for _, v := range i.values {
+		if _, exists := found[v.UserID]; exists || v.UserID == 0 {
+			continue
+		}
+		found[v.UserID] = struct{}{}
+		keys = append(keys, v.UserID)
+	}
+	s := NewUsersInstance()
+	if len(keys) == 0 {
+		return s, nil
+	}
+	es, err := d.FindByIDs(keys)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	for _, e := range es {
+		s.Add(&UserInstance{
+			User:    e,
+			userDao: d,
+		})
+	}
+	return s, nil
+}
+
+func (i *UserFriendsInstance) OtherUsers(d dao.User) (*UsersInstance, error) {
+	keys := []uint64{}
+	found := map[uint64]struct{}{}
+	for _, v := range i.values {
+		if _, exists := found[v.OtherUserID]; exists || v.OtherUserID == 0 {
+			continue
+		}
+		found[v.OtherUserID] = struct{}{}
+		keys = append(keys, v.OtherUserID)
+	}
+	s := NewUsersInstance()
+	if len(keys) == 0 {
+		return s, nil
+	}
+	es, err := d.FindByIDs(keys)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	for _, e := range es {
+		s.Add(&UserInstance{
+			User:    e,
+			userDao: d,
+		})
+	}
+	return s, nil
+}
+
 func (i *UserFriendsInstance) Save() error {
 	return i.EachWithError(func(i *UserFriendInstance) error {
 		return errors.Trace(i.Save())
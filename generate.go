@@ -0,0 +1,173 @@
+package remodel
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/juju/errors"
+)
+
+type Layer string
+
+type GenerateError struct {
+	Table string
+	Layer Layer
+	Err   error
+}
+
+// GenerateErrors collects every failure of a generate run.
+type GenerateErrors []*GenerateError
+
+func (e *GenerateError) Error() string {
+	// a diagnostic tells the table by itself
+	if d, ok := e.Err.(*Diagnostic); ok {
+		return fmt.Sprintf("[%s] %s", e.Layer, d)
+	}
+	if e.Table == "" {
+		return fmt.Sprintf("[%s] %s", e.Layer, e.Err)
+	}
+	return fmt.Sprintf("[%s] %s: %s", e.Layer, e.Table, e.Err)
+}
+
+func (s GenerateErrors) Error() string {
+	msgs := make([]string, 0, len(s))
+	for _, e := range s {
+		msgs = append(msgs, e.Error())
+	}
+	return fmt.Sprintf("%d error(s) occurred:\n%s", len(s), strings.Join(msgs, "\n"))
+}
+
+func (s *GenerateErrors) add(table string, layer Layer, err error) {
+	if err == nil {
+		return
+	}
+	*s = append(*s, &GenerateError{Table: table, Layer: layer, Err: err})
+}
+
+// split returns the errors and the diagnostics of warnings.
+func (s GenerateErrors) split() (GenerateErrors, GenerateErrors) {
+	var errs, warnings GenerateErrors
+	for _, e := range s {
+		if d, ok := e.Err.(*Diagnostic); ok && d.Severity == SeverityWarning {
+			warnings = append(warnings, e)
+			continue
+		}
+		errs = append(errs, e)
+	}
+	return errs, warnings
+}
+
+// logWarnings logs the diagnostics of warnings and returns the errors.
+func (s GenerateErrors) logWarnings() GenerateErrors {
+	errs, warnings := s.split()
+	for _, w := range warnings {
+		log.Print(w)
+	}
+	return errs
+}
+
+func (s GenerateErrors) orNil() error {
+	if len(s) == 0 {
+		return nil
+	}
+	return s
+}
+
+// Generate parses ddl once and outputs every layer (yaml, entity, proto, dao and model).
+// It does not stop at the first error, all of them are returned as GenerateErrors.
+func (s *Tables) Generate(cfg *Config) error {
+	var errs GenerateErrors
+
+	paths, err := sqlFiles(cfg)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	removed, parseErrs := s.parseAndLint(cfg, paths)
+	errs = append(errs, parseErrs...)
+	s.resolveConfig(cfg)
+	dir := yamlDir(cfg)
+	errs = append(errs, s.keepGoTypes(dir)...)
+	// files of a table whose ddl is broken must not be taken as orphans
+	canPrune := len(errs) == 0
+
+	files, renderErrs := renderParallel(cfg, len(*s), func(i int) ([]*generatedFile, GenerateErrors) {
+		t := (*s)[i]
+		if !cfg.matchTable(t.Name) {
+			return nil, nil
+		}
+		var errs GenerateErrors
+		f, err := t.render(dir)
+		if err != nil {
+			errs.add(t.Name, LayerYAML, err)
+			return nil, errs
+		}
+		files, errs := t.generate(cfg)
+		return append([]*generatedFile{f}, files...), errs
+	})
+	errs = append(errs, renderErrs...)
+	structable, err := s.Entities().renderStructable(cfg)
+	if err != nil {
+		errs.add("", LayerEntity, err)
+	} else {
+		files = append(files, structable)
+	}
+	if cfg.Module == "" {
+		errs.add("", LayerDao, errors.New("module name is required"))
+		errs.add("", LayerModel, errors.New("module name is required"))
+	}
+
+	errs = append(errs, writeGenerated(cfg, files)...)
+	switch {
+	case !canPrune:
+	case cfg.Prune:
+		// yaml of the dropped and renamed tables is pruned as well
+		errs.add("", LayerPrune, s.Prune(cfg))
+	default:
+		errs = append(errs, removeYAML(cfg, removed)...)
+	}
+	return errs.orNil()
+}
+
+// generate renders entity, proto, dao and model codes of the table.
+// dao and model are skipped without module name.
+func (t *Table) generate(cfg *Config) ([]*generatedFile, GenerateErrors) {
+	e := &Entity{}
+	e.fromTable(t)
+	files, errs := e.render(cfg)
+	if cfg.Module == "" {
+		return files, errs
+	}
+
+	d := &Dao{}
+	d.fromTable(t)
+	if f, err := d.render(cfg); err != nil {
+		errs.add(t.Name, LayerDao, err)
+	} else {
+		files = append(files, f)
+	}
+
+	m := &Model{}
+	m.fromTable(t)
+	if f, err := m.render(cfg); err != nil {
+		errs.add(t.Name, LayerModel, err)
+	} else {
+		files = append(files, f)
+	}
+
+	return files, errs
+}
+
+// Check regenerates every layer in memory and returns paths of files which are stale on disk.
+// Files of tables which no longer exist are stale as well, even without -prune.
+func (s *Tables) Check(cfg *Config) ([]string, error) {
+	w := &CheckWriter{RootDir: cfg.RootDir}
+	c := *cfg
+	c.Writer = w
+	// CheckWriter only lists the files to remove
+	c.Prune = true
+	if err := s.Generate(&c); err != nil {
+		return nil, err
+	}
+	return w.Stale, nil
+}
@@ -0,0 +1,354 @@
+package remodel
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yuki-eto/remodel/assert"
+)
+
+func TestTables_Generate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "remodel")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"users.sql": `
+CREATE TABLE users (
+  id BIGINT(20) UNSIGNED NOT NULL AUTO_INCREMENT,
+  name VARCHAR(40) NOT NULL,
+  created_at DATETIME,
+  updated_at DATETIME,
+  PRIMARY KEY (id)
+);`,
+		"item.sql": `
+CREATE TABLE item (
+  id BIGINT(20) UNSIGNED NOT NULL,
+  PRIMARY KEY (id)
+);`,
+		"broken.sql": "CREATE TABLE",
+	}
+	sqlDir := filepath.Join(dir, "schema", "sql")
+	if err := os.MkdirAll(sqlDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, body := range files {
+		if err := ioutil.WriteFile(filepath.Join(sqlDir, name), []byte(body), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg := NewConfig(dir)
+	cfg.Module = "example"
+	cfg.Dao.Dir = "infra/dao"
+	s := &Tables{}
+	err = s.Generate(cfg)
+	errs, ok := err.(GenerateErrors)
+	assert.True(t, ok)
+	assert.Len(t, errs, 2)
+	assert.Equals(t, errs[0].Table, "broken")
+	assert.Equals(t, errs[0].Layer, LayerYAML)
+	assert.Equals(t, errs[1].Table, "item")
+	assert.Equals(t, errs[1].Layer, LayerYAML)
+
+	for _, path := range []string{
+		filepath.Join("schema", "yaml", "users.yml"),
+		filepath.Join("entity", "user.go"),
+		filepath.Join("entity", "structable.go"),
+		filepath.Join("infra", "dao", "user.go"),
+		filepath.Join("model", "user.go"),
+	} {
+		if _, err := os.Stat(filepath.Join(dir, path)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	b, err := ioutil.ReadFile(filepath.Join(dir, "model", "user.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, strings.Contains(string(b), `"example/infra/dao"`))
+}
+
+func TestTables_Check(t *testing.T) {
+	dir, err := ioutil.TempDir("", "remodel")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	sqlDir := filepath.Join(dir, "schema", "sql")
+	for _, d := range []string{sqlDir, filepath.Join(dir, "entity"), filepath.Join(dir, "dao"), filepath.Join(dir, "model")} {
+		if err := os.MkdirAll(d, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ddl := `
+CREATE TABLE items (
+  id BIGINT(20) UNSIGNED NOT NULL,
+  name VARCHAR(40) NOT NULL,
+  PRIMARY KEY (id)
+);`
+	sqlPath := filepath.Join(sqlDir, "items.sql")
+	if err := ioutil.WriteFile(sqlPath, []byte(ddl), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := NewConfig(dir)
+	cfg.Module = "example"
+	if err := (&Tables{}).Generate(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	stale, err := (&Tables{}).Check(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, stale, 0)
+
+	ddl = strings.Replace(ddl, "name VARCHAR(40)", "label VARCHAR(40)", 1)
+	if err := ioutil.WriteFile(sqlPath, []byte(ddl), 0644); err != nil {
+		t.Fatal(err)
+	}
+	stale, err = (&Tables{}).Check(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equals(t, stale, []string{
+		"schema/yaml/items.yml",
+		"entity/item.go",
+		"model/item.go",
+	})
+}
+
+func TestTables_Prune(t *testing.T) {
+	dir, err := ioutil.TempDir("", "remodel")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	sqlDir := filepath.Join(dir, "schema", "sql")
+	for _, d := range []string{sqlDir, filepath.Join(dir, "entity"), filepath.Join(dir, "dao"), filepath.Join(dir, "model")} {
+		if err := os.MkdirAll(d, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, name := range []string{"user_items", "users"} {
+		ddl := "CREATE TABLE " + name + " (id BIGINT(20) UNSIGNED NOT NULL, PRIMARY KEY (id));"
+		if err := ioutil.WriteFile(filepath.Join(sqlDir, name+".sql"), []byte(ddl), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	handWritten := filepath.Join(dir, "dao", "user_item_helper.go")
+	if err := ioutil.WriteFile(handWritten, []byte("package dao\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := NewConfig(dir)
+	cfg.Module = "example"
+	cfg.Proto = true
+	cfg.Prune = true
+	if err := (&Tables{}).Generate(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(sqlDir, "user_items.sql")); err != nil {
+		t.Fatal(err)
+	}
+
+	// check reports the files of the deleted table without -prune as well
+	checkCfg := *cfg
+	checkCfg.Prune = false
+	stale, err := (&Tables{}).Check(&checkCfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equals(t, stale, []string{
+		"entity/structable.go",
+		"schema/yaml/user_items.yml",
+		"entity/user_item.go",
+		"schema/protobuf/user_item_entity.proto",
+		"dao/user_item.go",
+		"model/user_item.go",
+	})
+
+	if err := (&Tables{}).Generate(cfg); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range stale[1:] {
+		_, err := os.Stat(filepath.Join(dir, path))
+		assert.True(t, os.IsNotExist(err))
+	}
+	for _, path := range []string{handWritten, filepath.Join(dir, "entity", "user.go"), filepath.Join(dir, "dao", "user.go")} {
+		if _, err := os.Stat(path); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestTables_Generate_removedTables(t *testing.T) {
+	dir, err := ioutil.TempDir("", "remodel")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	sqlDir := filepath.Join(dir, "schema", "sql")
+	if err := os.MkdirAll(sqlDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	write := func(name, ddl string) {
+		if err := ioutil.WriteFile(filepath.Join(sqlDir, name), []byte(ddl), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("0001_create.sql", `
+CREATE TABLE gifts (id BIGINT(20) UNSIGNED NOT NULL, PRIMARY KEY (id));
+CREATE TABLE coupons (id BIGINT(20) UNSIGNED NOT NULL, PRIMARY KEY (id));
+CREATE TABLE items (id BIGINT(20) UNSIGNED NOT NULL, PRIMARY KEY (id));`)
+
+	cfg := NewConfig(dir)
+	cfg.Module = "example"
+	if err := (&Tables{}).Generate(cfg); err != nil {
+		t.Fatal(err)
+	}
+	yamlPath := func(name string) string {
+		return filepath.Join(dir, "schema", "yaml", name+".yml")
+	}
+	for _, name := range []string{"gifts", "coupons", "items"} {
+		if _, err := os.Stat(yamlPath(name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("0002_migrate.sql", `
+RENAME TABLE gifts TO presents;
+DROP TABLE coupons;`)
+	stale, err := (&Tables{}).Check(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equals(t, stale, []string{
+		"schema/yaml/presents.yml",
+		"entity/present.go",
+		"dao/present.go",
+		"model/present.go",
+		"entity/structable.go",
+		"schema/yaml/coupons.yml",
+		"schema/yaml/gifts.yml",
+		"entity/coupon.go",
+		"entity/gift.go",
+		"dao/coupon.go",
+		"dao/gift.go",
+		"model/coupon.go",
+		"model/gift.go",
+	})
+
+	// yaml is removed without -prune, the other layers are left to -prune
+	if err := (&Tables{}).Generate(cfg); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"gifts", "coupons"} {
+		_, err := os.Stat(yamlPath(name))
+		assert.True(t, os.IsNotExist(err))
+	}
+	for _, name := range []string{"presents", "items"} {
+		if _, err := os.Stat(yamlPath(name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the entity mode reads yaml, so the removed tables do not come back
+	ts := &Tables{}
+	if err := ts.Load(cfg); err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, t := range *ts {
+		names = append(names, t.Name)
+	}
+	assert.Equals(t, names, []string{"items", "presents"})
+}
+
+func TestTables_Generate_goType(t *testing.T) {
+	dir, err := ioutil.TempDir("", "remodel")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	sqlDir := filepath.Join(dir, "schema", "sql")
+	if err := os.MkdirAll(sqlDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	ddl := `
+CREATE TABLE user_states (
+  id BIGINT(20) UNSIGNED NOT NULL AUTO_INCREMENT,
+  user_id BIGINT(20) UNSIGNED NOT NULL,
+  state JSON,
+  created_at DATETIME,
+  updated_at DATETIME,
+  PRIMARY KEY (id)
+);`
+	if err := ioutil.WriteFile(filepath.Join(sqlDir, "user_states.sql"), []byte(ddl), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := NewConfig(dir)
+	cfg.Module = "example"
+	if err := (&Tables{}).Generate(cfg); err != nil {
+		t.Fatal(err)
+	}
+	ymlPath := filepath.Join(dir, "schema", "yaml", "user_states.yml")
+	yml, err := ioutil.ReadFile(ymlPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	yml = []byte(strings.Replace(string(yml), "entity_type: json.RawMessage\n", "entity_type: json.RawMessage\n  go_type: '*example/entity/state.Game'\n", 1))
+	if err := ioutil.WriteFile(ymlPath, yml, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// go_type written by hand survives the regeneration
+	if err := (&Tables{}).Generate(cfg); err != nil {
+		t.Fatal(err)
+	}
+	yml, err = ioutil.ReadFile(ymlPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, strings.Contains(string(yml), "go_type: '*example/entity/state.Game'"))
+	b, err := ioutil.ReadFile(filepath.Join(dir, "entity", "user_state.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"State     *state.Game",
+		// nil is saved as NULL, not as the JSON null
+		"var stateJSON []byte\n\tif e.State != nil {\n\t\tb, err := json.Marshal(e.State)",
+		`enc.Bytes("state", stateJSON)`,
+		`if err := json.Unmarshal(b, &e.State); err != nil {`,
+	} {
+		if !strings.Contains(string(b), want) {
+			t.Errorf("entity does not contain %q", want)
+		}
+	}
+	b, err = ioutil.ReadFile(filepath.Join(dir, "dao", "user_state.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, strings.Contains(string(b), `"state":      stateJSON,`))
+
+	// NULL of a nullable column cannot be kept by a value type
+	yml = []byte(strings.Replace(string(yml), "go_type: '*example/entity/state.Game'", "go_type: example/entity/state.Game", 1))
+	if err := ioutil.WriteFile(ymlPath, yml, 0644); err != nil {
+		t.Fatal(err)
+	}
+	err = (&Tables{}).Generate(cfg)
+	assert.NotEquals(t, err, nil)
+	assert.True(t, strings.Contains(err.Error(), "go_type of nullable column state must be a pointer to keep NULL: example/entity/state.Game"))
+}
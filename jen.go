@@ -1,11 +1,14 @@
 package remodel
 
 import (
+	"strings"
+
 	"github.com/dave/jennifer/jen"
 )
 
 type code = jen.Code
 type statement = jen.Statement
+type file = jen.File
 type cmap map[code]code
 
 func newFile(packageName string) *jen.File {
@@ -67,8 +70,8 @@ func rtn(results ...code) *statement {
 	return jen.Return(results...)
 }
 
-func traceErr(codes ...code) *statement {
-	q := qual(ErrorsLib, "Trace")
+func traceErr(errorsLib string, codes ...code) *statement {
+	q := qual(errorsLib, "Trace")
 	if len(codes) > 0 {
 		return q.Call(codes[0])
 	}
@@ -173,3 +176,35 @@ func bools(b bool) *statement {
 func jmap(code code) *statement {
 	return jen.Map(code)
 }
+
+func jnew(code code) *statement {
+	return jen.New(code)
+}
+
+func jparens(code code) *statement {
+	return jen.Parens(code)
+}
+
+func jswitch(code code) *statement {
+	return jen.Switch(code)
+}
+
+func jcase(codes ...code) *statement {
+	return jen.Case(codes...)
+}
+
+func jdefault() *statement {
+	return jen.Default()
+}
+
+// comments returns a comment per line of the text, nothing for an empty text.
+func comments(text string) []code {
+	if text == "" {
+		return nil
+	}
+	var codes []code
+	for _, l := range strings.Split(text, "\n") {
+		codes = append(codes, jen.Comment(strings.TrimRight(l, "\r")))
+	}
+	return codes
+}
@@ -0,0 +1,111 @@
+package remodel
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/iancoleman/strcase"
+	"github.com/juju/errors"
+)
+
+// keepGoTypes takes go_type of JSON and decimal columns from the yaml files on disk, which are written by hand.
+func (s Tables) keepGoTypes(yamlDir string) GenerateErrors {
+	var errs GenerateErrors
+	for _, t := range s {
+		path := filepath.Join(yamlDir, t.Name+".yml")
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			continue
+		}
+		current, err := loadTable(path)
+		if err != nil {
+			errs.add(t.Name, LayerYAML, err)
+			continue
+		}
+		for _, c := range current.Columns {
+			if col := t.column(c.Name); col != nil && (col.ColumnType == JSON || col.isDecimal()) {
+				col.GoType = c.GoType
+			}
+		}
+	}
+	return errs
+}
+
+// goTypeCode returns the type named by go_type like *example/entity/state.GameState.
+// A name without the import path is in the package of entityPackage, empty means the current package.
+func goTypeCode(goType, entityPackage string) code {
+	name := strings.TrimPrefix(goType, "*")
+	var t *statement
+	if idx := strings.LastIndex(name, "."); idx >= 0 {
+		t = qual(name[:idx], name[idx+1:])
+	} else if entityPackage != "" {
+		t = qual(entityPackage, name)
+	} else {
+		t = i(name)
+	}
+	if strings.HasPrefix(goType, "*") {
+		return ptr(t)
+	}
+	return t
+}
+
+// marshalledName returns the variable which holds the field of go_type marshalled to JSON.
+func marshalledName(fieldName string) string {
+	return strcase.ToLowerCamel(fieldName) + "JSON"
+}
+
+// marshalledValue returns the value of the field of go_type given to rapidash, which is made by marshalCode.
+func marshalledValue(fieldName, goType string, t EntityType) *statement {
+	if t == String && strings.HasPrefix(goType, "*") {
+		return i(textName(fieldName))
+	}
+	if t == String {
+		return i("string").Call(i(textName(fieldName)))
+	}
+	return i(marshalledName(fieldName))
+}
+
+// marshalCode marshals the field of go_type to JSON, or to text for a decimal type, before it is given to rapidash.
+// A nil pointer is nil bytes, which rapidash writes as NULL instead of the JSON null.
+func marshalCode(errorsLib, fieldName, goType string, t EntityType) []code {
+	if t == String {
+		return marshalTextCode(errorsLib, fieldName, goType)
+	}
+	name := marshalledName(fieldName)
+	if !strings.HasPrefix(goType, "*") {
+		return []code{
+			list(i(name), i("err")).Op(":=").Qual("encoding/json", "Marshal").Call(i("e").Dot(fieldName)),
+			ifErr().Block(rtn(traceErr(errorsLib))),
+		}
+	}
+	return []code{
+		jvar(name).Index().Byte(),
+		ifa(i("e").Dot(fieldName), "!=", null()).Block(
+			list(i("b"), i("err")).Op(":=").Qual("encoding/json", "Marshal").Call(i("e").Dot(fieldName)),
+			ifErr().Block(rtn(traceErr(errorsLib))),
+			i(name).Op("=").Id("b"),
+		),
+	}
+}
+
+// validateGoType rejects go_type of a nullable JSON or decimal column which is not a pointer,
+// because NULL would be decoded to the zero value and saved as its JSON or text.
+func (c *Column) validateGoType() error {
+	if (c.ColumnType == JSON || c.isDecimal()) && c.GoType != "" && !c.IsNotNull && !strings.HasPrefix(c.GoType, "*") {
+		return errors.Errorf("go_type of nullable column %s must be a pointer to keep NULL: %s", c.Name, c.GoType)
+	}
+	return nil
+}
+
+// unmarshalCode unmarshals JSON, or text for a decimal type, of the column into the field of go_type.
+// NULL leaves the field as it is.
+func unmarshalCode(errorsLib, fieldName, columnName, goType string, t EntityType) code {
+	if t == String {
+		return unmarshalTextCode(errorsLib, fieldName, columnName, goType)
+	}
+	return ifxBool(i("b").Op(":=").Id("dec").Dot("Bytes").Call(lit(columnName)), size(i("b")).Op(">").Lit(0)).Block(
+		ifxErr(qual("encoding/json", "Unmarshal").Call(i("b"), addr(i("e").Dot(fieldName)))).Block(
+			rtn(traceErr(errorsLib)),
+		),
+	)
+}
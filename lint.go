@@ -0,0 +1,222 @@
+package remodel
+
+import (
+	"regexp"
+
+	"github.com/gertd/go-pluralize"
+	"github.com/juju/errors"
+)
+
+// LintRule is a naming rule of tables and columns checked after the whole ddl is applied.
+type LintRule string
+
+const (
+	// LintPluralTable requires plural table names, entities are named after the singular.
+	LintPluralTable LintRule = "plural_table"
+	// LintSingularColumn requires singular names of the columns in indexes.
+	LintSingularColumn LintRule = "singular_column"
+	// LintSnakeCase requires snake_case names of tables and columns.
+	LintSnakeCase LintRule = "snake_case"
+	// LintTimestamps requires created_at and updated_at of writable tables, which dao sets on save.
+	LintTimestamps LintRule = "timestamps"
+	// LintMaxIdentifierLength limits the length of table, column and index names.
+	LintMaxIdentifierLength LintRule = "max_identifier_length"
+
+	// SeverityOff disables a lint rule.
+	SeverityOff Severity = "off"
+
+	// MySQL rejects longer identifiers
+	defaultMaxIdentifierLength = 64
+)
+
+// LintConfig is the severity of each rule and the waivers of tables and columns.
+type LintConfig struct {
+	Rules map[LintRule]Severity `yaml:"rules"`
+	// MaxIdentifierLength is the limit of max_identifier_length, 0 means 64.
+	MaxIdentifierLength int `yaml:"max_identifier_length"`
+	// Waivers are the rules which a table ("news") or a column ("user_settings.settings") does not follow.
+	Waivers map[string][]LintRule `yaml:"waivers"`
+}
+
+var snakeCaseRegexp = regexp.MustCompile(`^[a-z][a-z0-9]*(_[a-z0-9]+)*$`)
+
+// newLintConfig returns the default rules, the checks which used to be hard errors are still errors.
+func newLintConfig() LintConfig {
+	return LintConfig{
+		Rules: map[LintRule]Severity{
+			LintPluralTable:         SeverityError,
+			LintSingularColumn:      SeverityError,
+			LintSnakeCase:           SeverityOff,
+			LintTimestamps:          SeverityWarning,
+			LintMaxIdentifierLength: SeverityOff,
+		},
+	}
+}
+
+// UnmarshalYAML puts the written rules over the defaults in c.
+// yaml does not decode into the map of the defaults, which rejects the same keys in strict mode.
+func (c *LintConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain LintConfig
+	var written plain
+	if err := unmarshal(&written); err != nil {
+		return err
+	}
+	defaults := c.Rules
+	*c = LintConfig(written)
+	if c.Rules == nil {
+		c.Rules = map[LintRule]Severity{}
+	}
+	for rule, severity := range defaults {
+		if _, exists := c.Rules[rule]; !exists {
+			c.Rules[rule] = severity
+		}
+	}
+	return nil
+}
+
+func (c *LintConfig) validate() error {
+	for rule, severity := range c.Rules {
+		if !rule.isKnown() {
+			return errors.Errorf("unknown lint rule: %s", rule)
+		}
+		switch severity {
+		case SeverityError, SeverityWarning, SeverityOff:
+		default:
+			return errors.Errorf("unknown severity of %s: %s (expected %s, %s or %s)", rule, severity, SeverityError, SeverityWarning, SeverityOff)
+		}
+	}
+	for name, rules := range c.Waivers {
+		for _, rule := range rules {
+			if !rule.isKnown() {
+				return errors.Errorf("unknown lint rule in waivers of %s: %s", name, rule)
+			}
+		}
+	}
+	if c.MaxIdentifierLength < 0 {
+		return errors.Errorf("negative max_identifier_length: %d", c.MaxIdentifierLength)
+	}
+	return nil
+}
+
+func (r LintRule) isKnown() bool {
+	switch r {
+	case LintPluralTable, LintSingularColumn, LintSnakeCase, LintTimestamps, LintMaxIdentifierLength:
+		return true
+	}
+	return false
+}
+
+// severity returns the severity of the rule for the table or the column, SeverityOff when it is waived.
+func (c *LintConfig) severity(rule LintRule, table, column string) Severity {
+	name := table
+	if column != "" {
+		name += "." + column
+	}
+	for _, waived := range c.Waivers[name] {
+		if waived == rule {
+			return SeverityOff
+		}
+	}
+	if severity, exists := c.Rules[rule]; exists {
+		return severity
+	}
+	return SeverityOff
+}
+
+func (c *LintConfig) maxIdentifierLength() int {
+	if c.MaxIdentifierLength > 0 {
+		return c.MaxIdentifierLength
+	}
+	return defaultMaxIdentifierLength
+}
+
+// Lint parses every ddl file and returns the diagnostics of the parser, the relations and the lint rules including warnings.
+func (s *Tables) Lint(cfg *Config) (Diagnostics, error) {
+	paths, err := sqlFiles(cfg)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	var ds Diagnostics
+	_, errs := s.parseFiles(paths)
+	for _, e := range errs {
+		ds = append(ds, diagnostics(e.Table, e.Err)...)
+	}
+	ds = append(ds, s.resolveLoaders()...)
+	return append(ds, s.lint(cfg)...), nil
+}
+
+// parseAndLint parses the ddl files and checks the relations and the lint rules, warnings are logged and only errors are returned.
+// The names of the tables dropped or renamed by the ddl are returned as well.
+func (s *Tables) parseAndLint(cfg *Config, paths []string) ([]string, GenerateErrors) {
+	removed, errs := s.parseFiles(paths)
+	for _, d := range s.resolveLoaders() {
+		errs.add(d.Table, LayerModel, d)
+	}
+	for _, d := range s.lint(cfg) {
+		errs.add(d.Table, LayerYAML, d)
+	}
+	return removed, errs.logWarnings()
+}
+
+// lint checks the rules of the project against every table.
+func (s Tables) lint(cfg *Config) Diagnostics {
+	var ds Diagnostics
+	for _, t := range s {
+		ds = append(ds, t.lint(&cfg.Lint)...)
+	}
+	return ds
+}
+
+func (t *Table) lint(c *LintConfig) Diagnostics {
+	var ds Diagnostics
+	check := func(rule LintRule, column string, ok bool, format string, args ...interface{}) {
+		if ok {
+			return
+		}
+		severity := c.severity(rule, t.Name, column)
+		if severity == SeverityOff {
+			return
+		}
+		d := t.diagnostic(severity, column, errors.Errorf(format, args...))
+		d.Rule = rule
+		ds = append(ds, d)
+	}
+
+	p := pluralize.NewClient()
+	check(LintPluralTable, "", !p.IsSingular(t.Name), "not plural table name")
+	check(LintSnakeCase, "", snakeCaseRegexp.MatchString(t.Name), "not snake_case table name")
+	check(LintMaxIdentifierLength, "", len(t.Name) <= c.maxIdentifierLength(), "table name is longer than %d", c.maxIdentifierLength())
+
+	indexed := map[string]struct{}{}
+	for _, index := range t.Indexes {
+		for _, columnName := range index.Columns {
+			indexed[columnName] = struct{}{}
+		}
+		if !index.IsPrimaryKey {
+			check(LintMaxIdentifierLength, "", len(index.Name) <= c.maxIdentifierLength(), "index name %s is longer than %d", index.Name, c.maxIdentifierLength())
+		}
+	}
+	for _, col := range t.Columns {
+		if _, exists := indexed[col.Name]; exists {
+			check(LintSingularColumn, col.Name, !p.IsPlural(col.Name), "not singular column name")
+		}
+		check(LintSnakeCase, col.Name, snakeCaseRegexp.MatchString(col.Name), "not snake_case column name")
+		check(LintMaxIdentifierLength, col.Name, len(col.Name) <= c.maxIdentifierLength(), "column name is longer than %d", c.maxIdentifierLength())
+	}
+
+	if !t.IsReadOnly {
+		for _, name := range []string{"created_at", "updated_at"} {
+			check(LintTimestamps, "", t.column(name) != nil, "dao sets %s on save but the table does not have it", name)
+		}
+	}
+	return ds
+}
+
+// diagnostic makes a diagnostic of the table located in CREATE TABLE.
+func (t *Table) diagnostic(severity Severity, column string, err error) *Diagnostic {
+	d := &Diagnostic{Severity: severity, Path: t.path, Table: t.Name, Column: column, Err: err}
+	if t.src != "" {
+		d.locate(t.src, t.src[t.start:t.end], t.start)
+	}
+	return d
+}
@@ -0,0 +1,88 @@
+package remodel
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yuki-eto/remodel/assert"
+)
+
+func TestTables_lint(t *testing.T) {
+	ddl := `-- news are not plural, but the comment is not the table
+CREATE TABLE news (
+  id BIGINT(20) UNSIGNED NOT NULL,
+  PRIMARY KEY (id)
+);
+CREATE TABLE user_settings (
+  id BIGINT(20) UNSIGNED NOT NULL,
+  settings VARCHAR(40) NOT NULL,
+  updatedAt DATETIME,
+  PRIMARY KEY (id),
+  KEY idx_settings (settings)
+);
+`
+	tables := Tables{}
+	assert.Len(t, tables.parseDDL(ddl), 0)
+
+	messages := func(ds Diagnostics) []string {
+		var msgs []string
+		for _, d := range ds {
+			msgs = append(msgs, d.Error())
+		}
+		return msgs
+	}
+
+	t.Run("default", func(t *testing.T) {
+		ds := tables.lint(NewConfig(""))
+		assert.Equals(t, messages(ds), []string{
+			"2:14: error: news: not plural table name (plural_table)",
+			"8:3: error: user_settings.settings: not singular column name (singular_column)",
+			"6:14: warning: user_settings: dao sets created_at on save but the table does not have it (timestamps)",
+			"6:14: warning: user_settings: dao sets updated_at on save but the table does not have it (timestamps)",
+		})
+	})
+
+	t.Run("rules_and_waivers", func(t *testing.T) {
+		cfg := NewConfig("")
+		cfg.Lint.Rules[LintSnakeCase] = SeverityError
+		cfg.Lint.Rules[LintTimestamps] = SeverityOff
+		cfg.Lint.Rules[LintMaxIdentifierLength] = SeverityWarning
+		cfg.Lint.MaxIdentifierLength = 12
+		cfg.Lint.Waivers = map[string][]LintRule{
+			"news":                   {LintPluralTable},
+			"user_settings.settings": {LintSingularColumn},
+		}
+		ds := tables.lint(cfg)
+		assert.Equals(t, messages(ds), []string{
+			"6:14: warning: user_settings: table name is longer than 12 (max_identifier_length)",
+			"9:3: error: user_settings.updatedAt: not snake_case column name (snake_case)",
+		})
+		assert.Equals(t, ds[1].Column, "updatedAt")
+	})
+}
+
+func TestTables_Lint(t *testing.T) {
+	dir, err := ioutil.TempDir("", "remodel")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	sqlDir := filepath.Join(dir, "schema", "sql")
+	if err := os.MkdirAll(sqlDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(sqlDir, "news.sql"), []byte("CREATE TABLE news (id BIGINT(20) NOT NULL, PRIMARY KEY (id));\nCREATE TABLE item (id BIGINT(20) NOT NULL);\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ds, err := (&Tables{}).Lint(NewConfig(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, ds, 2)
+	assert.True(t, strings.HasSuffix(ds[0].Error(), "news.sql:2:14: error: item: statement #2: need primary key"))
+	assert.True(t, strings.HasSuffix(ds[1].Error(), "news.sql:1:14: error: news: not plural table name (plural_table)"))
+}
@@ -1,10 +1,9 @@
 package remodel
 
 import (
+	"bytes"
 	"fmt"
 	"io"
-	"log"
-	"os"
 	"path/filepath"
 	"strings"
 
@@ -14,15 +13,17 @@ import (
 )
 
 type Model struct {
-	Name       string
-	SliceName  string
-	DaoName    string
-	Columns    []*ModelColumn
-	IsReadOnly bool
-	IDType     string
-	HasTime    bool
-	HasBytes   bool
-	HasStrings bool
+	Name        string
+	SliceName   string
+	TableName   string
+	DaoName     string
+	Columns     []*ModelColumn
+	PrimaryKeys []*ModelColumn
+	Relations   []*ModelRelation
+	IsReadOnly  bool
+	HasTime     bool
+	HasBytes    bool
+	HasStrings  bool
 }
 
 type Models []*Model
@@ -33,41 +34,81 @@ type ModelColumn struct {
 	CamelPluralName string
 }
 
-func (s *Models) Output(rootPath, moduleName string) error {
-	for _, m := range *s {
-		modelPath, err := filepath.Abs(filepath.Join(rootPath, "model", fmt.Sprintf("%s.go", strcase.ToSnake(m.Name))))
-		if err != nil {
-			return errors.Trace(err)
-		}
+// ModelRelation is an association loader from the column to the model of the referenced table.
+type ModelRelation struct {
+	Name         string
+	PluralName   string
+	Column       *ModelColumn
+	RefName      string
+	RefSliceName string
+	RefField     string
+	RefFields    string
+	RefReadOnly  bool
+}
 
-		out, err := os.Create(modelPath)
-		if err != nil {
-			return errors.Trace(err)
-		}
-		if _, err := out.WriteString("// Code generated by generate_code script - DO NOT EDIT.\n"); err != nil {
-			return errors.Trace(err)
-		}
-		if err := m.generateCode(out, moduleName); err != nil {
-			return errors.Trace(err)
+// instance makes the instance of the referenced model from e given by the dao d.
+func (r *ModelRelation) instance() code {
+	values := cmap{
+		i(r.RefName): i("e"),
+	}
+	if !r.RefReadOnly {
+		values[i(strcase.ToLowerCamel(r.RefName)+"Dao")] = i("d")
+	}
+	return addr(i(r.RefName + "Instance")).Add(vals(values))
+}
+
+func (s *Models) Output(cfg *Config) error {
+	files, errs := renderParallel(cfg, len(*s), func(i int) ([]*generatedFile, GenerateErrors) {
+		m := (*s)[i]
+		if !cfg.matchTable(m.TableName) {
+			return nil, nil
 		}
-		if err := out.Close(); err != nil {
-			return errors.Trace(err)
+		var errs GenerateErrors
+		f, err := m.render(cfg)
+		if err != nil {
+			errs.add(m.TableName, LayerModel, err)
+			return nil, errs
 		}
+		return []*generatedFile{f}, nil
+	})
 
-		if err := applyGoimports(modelPath); err != nil {
-			return errors.Trace(err)
-		}
+	errs = append(errs, writeGenerated(cfg, files)...)
+	return errs.orNil()
+}
+
+func (m *Model) codePath(cfg *Config) (string, error) {
+	dir, err := cfg.layerDir(cfg.Model)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	return filepath.Join(dir, fmt.Sprintf("%s.go", strcase.ToSnake(m.Name))), nil
+}
 
-		log.Printf("output: %s", modelPath)
+func (m *Model) render(cfg *Config) (*generatedFile, error) {
+	modelPath, err := m.codePath(cfg)
+	if err != nil {
+		return nil, errors.Trace(err)
 	}
 
-	return nil
+	out := &bytes.Buffer{}
+	out.WriteString(generatedCodeHeader)
+	if err := m.generateCode(out, cfg); err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	b, err := applyGoimports(modelPath, out.Bytes())
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	return &generatedFile{table: m.TableName, layer: LayerModel, path: modelPath, body: b}, nil
 }
 
 func (m *Model) fromTable(t *Table) {
 	p := pluralize.NewClient()
 	m.Name = strcase.ToCamel(p.Singular(t.Name))
 	m.SliceName = p.Plural(m.Name)
+	m.TableName = t.Name
 	m.DaoName = strcase.ToLowerCamel(m.Name) + "Dao"
 	m.Columns = []*ModelColumn{}
 	m.IsReadOnly = t.IsReadOnly
@@ -85,9 +126,6 @@ func (m *Model) fromTable(t *Table) {
 			CamelName:       fieldName,
 			CamelPluralName: fieldsName,
 		}
-		if c.Name == "id" {
-			m.IDType = string(c.EntityType)
-		}
 		if c.EntityType == ByteSlice {
 			m.HasBytes = true
 		} else if c.EntityType == StringSlice {
@@ -97,17 +135,100 @@ func (m *Model) fromTable(t *Table) {
 		}
 		m.Columns = append(m.Columns, col)
 	}
+	for _, c := range t.primaryKeyColumns() {
+		for _, col := range m.Columns {
+			if col.Name == c.Name {
+				m.PrimaryKeys = append(m.PrimaryKeys, col)
+			}
+		}
+	}
+	m.fromRelations(t, p)
+}
+
+// fromRelations makes the association loaders of the relations which resolveLoaders allows.
+// The loader is named after the column without `_id` like OtherUser, or after the column and the referenced model.
+func (m *Model) fromRelations(t *Table, p *pluralize.Client) {
+	names := map[string]struct{}{m.Name: {}}
+	for _, r := range t.Relations {
+		if !r.hasLoader {
+			continue
+		}
+		var col *ModelColumn
+		for _, c := range m.Columns {
+			if c.Name == r.Columns[0] {
+				col = c
+			}
+		}
+		if col == nil {
+			continue
+		}
+		refName := strcase.ToCamel(p.Singular(r.RefTable))
+		name := toFieldName(strings.TrimSuffix(col.Name, "_id"))
+		if !strings.HasSuffix(col.Name, "_id") {
+			name += refName
+		}
+		if _, exists := names[name]; exists {
+			continue
+		}
+		names[name] = struct{}{}
+		refField := toFieldName(r.RefColumns[0])
+		refFields := p.Plural(refField)
+		if strings.HasSuffix(refField, "ID") {
+			refFields = refField + "s"
+		}
+		m.Relations = append(m.Relations, &ModelRelation{
+			Name:         name,
+			PluralName:   p.Plural(name),
+			Column:       col,
+			RefName:      refName,
+			RefSliceName: p.Plural(refName),
+			RefField:     refField,
+			RefFields:    refFields,
+			RefReadOnly:  isReadOnlyTable(r.RefTable),
+		})
+	}
+}
+
+// named returns the type defined in the entity package for an enum or a set column, nil for the others.
+func (m *Model) named(entityPackage string, c *ModelColumn) code {
+	if len(c.EnumValues) == 0 {
+		return nil
+	}
+	return qual(entityPackage, enumTypeName(m.Name, c.CamelName))
+}
+
+// columnType returns the type of the column in the entity.
+func (m *Model) columnType(entityPackage string, c *ModelColumn) code {
+	if goType := c.fieldGoType(); goType != "" {
+		return goTypeCode(goType, entityPackage)
+	}
+	return nullableTypeCode(c.goType(), c.nullMode(), m.named(entityPackage, c))
+}
+
+// valueType returns the type of a value of the column which is not NULL.
+func (m *Model) valueType(entityPackage string, c *ModelColumn) code {
+	return valueTypeCode(c.goType(), m.named(entityPackage, c))
+}
+
+// relationKey returns the type of the referenced key and the key from the value of the column which is not NULL.
+// sql.Null* types may hold the value in a wider type than the key.
+func (m *Model) relationKey(entityPackage string, c *ModelColumn, value *statement) (code, *statement) {
+	if c.goType() != c.EntityType {
+		return i(string(c.EntityType)), i(string(c.EntityType)).Call(value)
+	}
+	return m.valueType(entityPackage, c), value
 }
 
-func (m *Model) generateCode(writer io.Writer, moduleName string) error {
-	f := newFile("model")
+func (m *Model) generateCode(writer io.Writer, cfg *Config) error {
+	f := newFile(cfg.Model.Package)
 
-	entityPackage := fmt.Sprintf("%s/%s", moduleName, EntityPackageName)
-	daoPackage := fmt.Sprintf("%s/%s", moduleName, DaoPackageName)
-	f.ImportName(entityPackage, EntityPackageName)
-	f.ImportName(daoPackage, DaoPackageName)
-	f.ImportName(LogLib, "log")
-	f.ImportName(ErrorsLib, "errors")
+	errorsLib := cfg.Libraries.Errors
+	entityPackage := cfg.importPath(cfg.Entity)
+	daoPackage := cfg.importPath(cfg.Dao)
+	f.ImportName(entityPackage, cfg.Entity.Package)
+	f.ImportName(daoPackage, cfg.Dao.Package)
+	f.ImportName(cfg.Libraries.Log, "log")
+	f.ImportName(errorsLib, "errors")
 	f.ImportName("sort", "sort")
 	if m.HasTime {
 		f.ImportName("time", "time")
@@ -118,8 +239,6 @@ func (m *Model) generateCode(writer io.Writer, moduleName string) error {
 	if m.HasStrings {
 		f.ImportName("strings", "strings")
 	}
-	idType := i(m.IDType)
-	idParam := i("id").Add(idType)
 	singleEntity := qual(entityPackage, m.Name)
 	ptrEntity := ptr().Add(singleEntity)
 	entityParam := i("e").Add(ptrEntity)
@@ -130,7 +249,7 @@ func (m *Model) generateCode(writer io.Writer, moduleName string) error {
 	sliceInstancePointer := ptr().Id(sliceInstanceName)
 
 	returnNil := rtn().Nil()
-	returnErr := rtn(traceErr())
+	returnErr := rtn(traceErr(errorsLib))
 
 	// define struct
 	f.Type().Id(m.Name + "Impl").Struct(
@@ -162,11 +281,29 @@ func (m *Model) generateCode(writer io.Writer, moduleName string) error {
 		for _, name := range []string{"Save", "Delete"} {
 			f.Add(pfn("i", instanceName).Id(name).Params().Error().Block(
 				ifa(idot("i", m.DaoName), "==", null()).Block(returnNil),
-				rtn(qual(ErrorsLib, "Trace").Call(i("i").Dot(m.DaoName).Dot(name).Call(e))),
+				rtn(qual(errorsLib, "Trace").Call(i("i").Dot(m.DaoName).Dot(name).Call(e))),
 			)).Line()
 		}
 	}
 
+	// association loaders
+	for _, r := range m.Relations {
+		value := idot("i", r.Column.CamelName)
+		isEmpty := value.Clone().Op("==").Add(r.Column.EntityType.zeroValue())
+		if mode := r.Column.nullMode(); mode != NullableNone {
+			isEmpty = nullableIsNull(value, mode)
+			_, v := nullableValue(value, r.Column.goType(), mode, nil)
+			_, value = m.relationKey(entityPackage, r.Column, v)
+		}
+		f.Add(pfn("i", instanceName).Id(r.Name).Params(i("d").Qual(daoPackage, r.RefName)).Params(ptr(i(r.RefName+"Instance")), jerr()).Block(
+			ifb(isEmpty).Block(rtn(null(), null())),
+			list(i("e"), i("err")).Op(":=").Id("d").Dot("FindBy"+r.RefField).Call(value.Clone()),
+			ifErr().Block(rtn(null(), traceErr(errorsLib))),
+			ifa(i("e"), "==", null()).Block(rtn(null(), null())),
+			rtn(r.instance(), null()),
+		)).Line()
+	}
+
 	f.Type().Id(sliceInstanceName).Struct(
 		i("values").Index().Add(instancePointer),
 	).Line()
@@ -183,14 +320,39 @@ func (m *Model) generateCode(writer io.Writer, moduleName string) error {
 		idot("i", "values").Op("=").Append(idot("i", "values"), i("v")),
 	)).Line()
 
-	// FindByID
-	valueID := idot("v", "ID")
-	f.Add(pfn("i", sliceInstanceName).Id("FindByID").Params(idParam).Params(instancePointer).Block(
-		forEachV("v", idot("i", "values")).Block(
-			ifa(valueID, "==", i("id")).Block(rtn(i("v"))),
-		),
-		returnNil,
-	)).Line()
+	if len(m.PrimaryKeys) > 1 {
+		// FindByPrimaryKey
+		var (
+			params []code
+			match  *statement
+		)
+		for _, pk := range m.PrimaryKeys {
+			name := strcase.ToLowerCamel(pk.CamelName)
+			params = append(params, i(name).Add(m.columnType(entityPackage, pk)))
+			cond := idot("v", pk.CamelName).Op("==").Id(name)
+			if match == nil {
+				match = cond
+			} else {
+				match = match.Op("&&").Add(cond)
+			}
+		}
+		f.Add(pfn("i", sliceInstanceName).Id("FindByPrimaryKey").Params(params...).Params(instancePointer).Block(
+			forEachV("v", idot("i", "values")).Block(
+				ifb(match).Block(rtn(i("v"))),
+			),
+			returnNil,
+		)).Line()
+	} else {
+		// FindByID
+		pk := m.PrimaryKeys[0]
+		name := strcase.ToLowerCamel(pk.CamelName)
+		f.Add(pfn("i", sliceInstanceName).Id("FindBy"+pk.CamelName).Params(i(name).Add(m.columnType(entityPackage, pk))).Params(instancePointer).Block(
+			forEachV("v", idot("i", "values")).Block(
+				ifa(idot("v", pk.CamelName), "==", i(name)).Block(rtn(i("v"))),
+			),
+			returnNil,
+		)).Line()
+	}
 
 	// FilterBy
 	cbFunc := i("f").Func().Params(instancePointer).Bool()
@@ -243,14 +405,25 @@ func (m *Model) generateCode(writer io.Writer, moduleName string) error {
 		rtn(i("i").Dot("Len").Call().Op("==").Lit(0)),
 	)).Line()
 
+	// return Column slice
+	columnsGetter := func(c *ModelColumn, columnType code) code {
+		return pfn("i", sliceInstanceName).Id(c.CamelPluralName).Params().Params(idx().Add(columnType)).Block(
+			i("s").Op(":=").Index().Add(columnType).Values(),
+			idot("i", "Each").Call(fn().Params(i("v").Add(instancePointer)).Block(
+				i("s").Op("=").Append(i("s"), idot("v", c.CamelName)),
+			)),
+			rtn(i("s")),
+		)
+	}
+
 	for _, c := range m.Columns {
-		var columnType code
-		if c.EntityType == TimePtr {
-			columnType = ptr().Qual("time", "Time")
-		} else {
-			columnType = i(string(c.EntityType))
-		}
+		columnType := m.columnType(entityPackage, c)
 		valueField := idot("v", c.CamelName)
+		if c.EntityType == RawJSON || c.decimalType() != "" {
+			// JSON and decimal types are neither comparable nor ordered with operators
+			f.Add(columnsGetter(c, columnType)).Line()
+			continue
+		}
 
 		// FilterByColumn
 		filterCodes := []code{
@@ -263,7 +436,20 @@ func (m *Model) generateCode(writer io.Writer, moduleName string) error {
 		forBlock := ifa(valueField, "==", i("c")).Block(
 			idot("s", "Add").Call(i("v")),
 		)
-		if c.EntityType == TimePtr {
+		if mode := c.nullMode(); mode != NullableNone {
+			valid, value := nullableValue(valueField, c.goType(), mode, m.named(entityPackage, c))
+			match := value.Clone().Op("==").Id("c")
+			if c.goType() == TimeValue {
+				match = nullableReceiver(value, mode).Dot("Equal").Call(i("c"))
+			}
+			forBlock = ifb(valid.Op("&&").Add(match)).Block(
+				idot("s", "Add").Call(i("v")),
+			)
+		} else if c.goType() == TimeValue {
+			forBlock = ifb(i("v").Dot(c.CamelName).Dot("Equal").Call(i("c"))).Block(
+				idot("s", "Add").Call(i("v")),
+			)
+		} else if c.EntityType == TimePtr {
 			forBlock = ifb(i("v").Dot(c.CamelName).Dot("Equal").Call(ptr(i("c")))).Block(
 				idot("s", "Add").Call(i("v")),
 			)
@@ -280,26 +466,65 @@ func (m *Model) generateCode(writer io.Writer, moduleName string) error {
 		}
 		filterCodes = append(filterCodes, forEachV("v", idot("i", "values")).Block(forBlock), rtn(i("s")))
 		name := "FilterBy" + c.CamelName
-		f.Add(pfn("i", sliceInstanceName).Id(name).Params(i("c").Add(columnType)).Params(sliceInstancePointer).Block(
+		f.Add(pfn("i", sliceInstanceName).Id(name).Params(i("c").Add(m.valueType(entityPackage, c))).Params(sliceInstancePointer).Block(
 			filterCodes...,
 		)).Line()
 
+		if c.ColumnType == Set && len(c.EnumValues) > 0 {
+			// FilterByColumnContaining
+			f.Add(pfn("i", sliceInstanceName).Id(name+"Containing").Params(i("member").String()).Params(sliceInstancePointer).Block(
+				i("s").Op(":=").Id("New"+sliceInstanceName).Call(),
+				forEachV("v", idot("i", "values")).Block(
+					ifb(i("v").Dot(c.CamelName).Dot("Has").Call(i("member"))).Block(
+						idot("s", "Add").Call(i("v")),
+					),
+				),
+				rtn(i("s")),
+			)).Line()
+		}
+
+		if c.isDecimal() {
+			// strings of decimals are not ordered by value
+			f.Add(columnsGetter(c, columnType)).Line()
+			continue
+		}
+
 		// SortByColumn
 		valueI := i("s").Dot("values").Index(i("i")).Dot(c.CamelName)
 		valueJ := i("s").Dot("values").Index(i("j")).Dot(c.CamelName)
 		descCompare := valueI.Clone().Op(">").Add(valueJ)
 		ascCompare := valueI.Clone().Op("<").Add(valueJ)
-		switch c.EntityType {
-		case TimePtr:
+		switch mode := c.nullMode(); {
+		case mode != NullableNone:
+			// NULL comes first in ascending order like MySQL
+			named := m.named(entityPackage, c)
+			validI, vI := nullableValue(valueI, c.goType(), mode, named)
+			validJ, vJ := nullableValue(valueJ, c.goType(), mode, named)
+			greater := vI.Clone().Op(">").Add(vJ)
+			less := vI.Clone().Op("<").Add(vJ)
+			switch c.goType() {
+			case TimeValue:
+				greater = nullableReceiver(vI, mode).Dot("After").Call(vJ)
+				less = nullableReceiver(vI, mode).Dot("Before").Call(vJ)
+			case Bool:
+				greater = vI.Clone().Op("&&").Op("!").Add(vJ)
+				less = op("!").Add(vI).Op("&&").Add(vJ)
+			}
+			descCompare = validI.Op("&&").Parens(nullableIsNull(valueJ, mode).Op("||").Add(greater))
+			ascCompare = validJ.Op("&&").Parens(nullableIsNull(valueI, mode).Op("||").Add(less))
+		case c.goType() == TimeValue:
+			descCompare = valueI.Clone().Dot("After").Call(valueJ)
+			ascCompare = valueI.Clone().Dot("Before").Call(valueJ)
+		case c.EntityType == TimePtr:
 			descCompare = valueI.Clone().Dot("Before").Call(ptr(valueJ))
 			ascCompare = valueI.Clone().Dot("After").Call(ptr(valueJ))
-		case Bool:
+		case c.EntityType == Bool:
 			descCompare = valueJ
 			ascCompare = valueI
-		case ByteSlice:
+		case c.EntityType == ByteSlice:
 			descCompare = qual("bytes", "Compare").Call(valueI, valueJ).Op(">").Lit(0)
 			ascCompare = qual("bytes", "Compare").Call(valueI, valueJ).Op("<").Lit(0)
-		case StringSlice:
+		case c.EntityType == StringSlice:
 			joinI := qual("strings", "Join").Call(valueI, lit(","))
 			joinJ := qual("strings", "Join").Call(valueJ, lit(","))
 			descCompare = joinI.Clone().Op("<").Add(joinJ)
@@ -315,20 +540,53 @@ func (m *Model) generateCode(writer io.Writer, moduleName string) error {
 			rtn(i("s")),
 		)).Line()
 
-		// return Column slice
-		f.Add(pfn("i", sliceInstanceName).Id(c.CamelPluralName).Params().Params(idx().Add(columnType)).Block(
-			i("s").Op(":=").Index().Add(columnType).Values(),
-			idot("i", "Each").Call(fn().Params(i("v").Add(instancePointer)).Block(
-				i("s").Op("=").Append(i("s"), valueField),
-			)),
-			rtn(i("s")),
+		f.Add(columnsGetter(c, columnType)).Line()
+	}
+
+	// association loaders at once to avoid querying per instance
+	for _, r := range m.Relations {
+		keyType := m.columnType(entityPackage, r.Column)
+		value := idot("v", r.Column.CamelName)
+		collect := []code{
+			ifx(list(op("_"), i("exists")).Op(":=").Id("found").Index(value), i("exists"), "||", value.Clone().Op("==").Add(r.Column.EntityType.zeroValue())).Block(
+				jcontinue(),
+			),
+			i("found").Index(value.Clone()).Op("=").Struct().Values(),
+			i("keys").Op("=").Append(i("keys"), value.Clone()),
+		}
+		if mode := r.Column.nullMode(); mode != NullableNone {
+			_, v := nullableValue(value, r.Column.goType(), mode, nil)
+			keyType, v = m.relationKey(entityPackage, r.Column, v)
+			collect = []code{
+				ifb(nullableIsNull(value, mode)).Block(jcontinue()),
+				i("k").Op(":=").Add(v),
+				ifxBool(list(op("_"), i("exists")).Op(":=").Id("found").Index(i("k")), i("exists")).Block(
+					jcontinue(),
+				),
+				i("found").Index(i("k")).Op("=").Struct().Values(),
+				i("keys").Op("=").Append(i("keys"), i("k")),
+			}
+		}
+		refSliceInstance := r.RefSliceName + "Instance"
+		f.Add(pfn("i", sliceInstanceName).Id(r.PluralName).Params(i("d").Qual(daoPackage, r.RefName)).Params(ptr(i(refSliceInstance)), jerr()).Block(
+			i("keys").Op(":=").Index().Add(keyType).Values(),
+			i("found").Op(":=").Map(keyType).Struct().Values(),
+			forEachV("v", idot("i", "values")).Block(collect...),
+			i("s").Op(":=").Id("New"+refSliceInstance).Call(),
+			ifa(size(i("keys")), "==", lit(0)).Block(rtn(i("s"), null())),
+			list(i("es"), i("err")).Op(":=").Id("d").Dot("FindBy"+r.RefFields).Call(i("keys")),
+			ifErr().Block(rtn(null(), traceErr(errorsLib))),
+			forEachV("e", i("es")).Block(
+				idot("s", "Add").Call(r.instance()),
+			),
+			rtn(i("s"), null()),
 		)).Line()
 	}
 
 	if !m.IsReadOnly {
 		f.Add(pfn("i", sliceInstanceName).Id("Save").Params().Params(jerr()).Block(
 			rtn(i("i").Dot("EachWithError").Call(fn().Params(i("i").Add(ptr(i(instanceName)))).Params(jerr()).Block(
-				rtn(traceErr(i("i").Dot("Save").Call())),
+				rtn(traceErr(errorsLib, i("i").Dot("Save").Call())),
 			))),
 		)).Line()
 	}
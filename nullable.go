@@ -0,0 +1,167 @@
+package remodel
+
+import (
+	"github.com/iancoleman/strcase"
+	"github.com/juju/errors"
+)
+
+// NullableMode is how a project holds NULL of nullable columns in Go.
+type NullableMode string
+
+const (
+	// NullableNone keeps the plain types, NULL cannot be told from the zero value and every time column is *time.Time.
+	NullableNone NullableMode = ""
+	// NullablePointer holds nullable columns as pointers like *int32, NOT NULL time columns become time.Time.
+	NullablePointer NullableMode = "pointer"
+	// NullableSQL holds nullable columns as sql.Null* types like sql.NullInt64, NOT NULL time columns become time.Time.
+	NullableSQL NullableMode = "sql"
+)
+
+func (m NullableMode) validate() error {
+	switch m {
+	case NullableNone, NullablePointer, NullableSQL:
+		return nil
+	}
+	return errors.Errorf("unknown nullable mode: %s (expected %s or %s)", m, NullablePointer, NullableSQL)
+}
+
+// sqlNullValueTypes maps a type to the value type of the sql.Null* type which holds it.
+// uint64 has none, values over math.MaxInt64 overflow sql.NullInt64.
+var sqlNullValueTypes = map[EntityType]EntityType{
+	Int8:      Int32,
+	Int16:     Int32,
+	Int32:     Int32,
+	Uint8:     Int32,
+	Uint16:    Int32,
+	Int64:     Int64,
+	Uint32:    Int64,
+	Float32:   Float64,
+	Float64:   Float64,
+	Bool:      Bool,
+	String:    String,
+	TimeValue: TimeValue,
+}
+
+// sqlNullName returns the name of the sql.Null* type of the value type, e.g. NullInt64.
+func sqlNullName(t EntityType) string {
+	return "Null" + sqlNullField(t)
+}
+
+// sqlNullField returns the field of the sql.Null* type which holds the value, e.g. Int64.
+func sqlNullField(t EntityType) string {
+	if t == TimeValue {
+		return "Time"
+	}
+	return strcase.ToCamel(string(t))
+}
+
+// isNullable reports whether NULL of the column is held in Go.
+// Keys are never NULL, and nil of []byte, []string, json.RawMessage and a pointer to a decimal type already stands for NULL.
+func (c *Column) isNullable() bool {
+	if c.Nullable == NullableNone || c.IsNotNull || c.IsPrimaryKey || c.IsAutoIncrement || c.decimalType() != "" {
+		return false
+	}
+	return c.EntityType != ByteSlice && c.EntityType != StringSlice && c.EntityType != RawJSON
+}
+
+// nullMode returns how NULL of the column is held, NullableNone when it is not nullable.
+// uint64 falls back to a pointer in sql mode, since no sql.Null* type holds it.
+func (c *Column) nullMode() NullableMode {
+	if !c.isNullable() {
+		return NullableNone
+	}
+	if c.Nullable == NullableSQL && c.EntityType == Uint64 {
+		return NullablePointer
+	}
+	return c.Nullable
+}
+
+// goType returns the type of a value of the column which is not NULL.
+func (c *Column) goType() EntityType {
+	t := c.EntityType
+	if c.Nullable == NullableNone {
+		return t
+	}
+	if t == TimePtr {
+		t = TimeValue
+	}
+	if c.nullMode() == NullableSQL {
+		return sqlNullValueTypes[t]
+	}
+	return t
+}
+
+// valueTypeCode returns the type of a value which is not NULL, named is the type defined for an enum or nil.
+func valueTypeCode(t EntityType, named code) code {
+	switch {
+	case named != nil:
+		return named
+	case t == TimeValue:
+		return qual("time", "Time")
+	case t == TimePtr:
+		return ptr().Qual("time", "Time")
+	case t == RawJSON:
+		return qual("encoding/json", "RawMessage")
+	}
+	return i(string(t))
+}
+
+// nullableTypeCode returns the type which holds the value of the column including NULL.
+func nullableTypeCode(t EntityType, mode NullableMode, named code) code {
+	switch mode {
+	case NullablePointer:
+		return ptr(valueTypeCode(t, named))
+	case NullableSQL:
+		return qual("database/sql", sqlNullName(t))
+	}
+	return valueTypeCode(t, named)
+}
+
+// nullableValue returns the condition that v is not NULL and the value of v.
+// named converts the string of sql.NullString to the type defined for an enum.
+func nullableValue(v *statement, t EntityType, mode NullableMode, named code) (*statement, *statement) {
+	if mode == NullableSQL {
+		value := v.Clone().Dot(sqlNullField(t))
+		if named != nil {
+			value = list(named).Call(value)
+		}
+		return v.Clone().Dot("Valid"), value
+	}
+	return v.Clone().Op("!=").Nil(), ptr(v.Clone())
+}
+
+// nullableIsNull returns the condition that v is NULL.
+func nullableIsNull(v *statement, mode NullableMode) *statement {
+	if mode == NullableSQL {
+		return op("!").Add(v.Clone()).Dot("Valid")
+	}
+	return v.Clone().Op("==").Nil()
+}
+
+// nullableReceiver makes a value of nullableValue the receiver of a method call like (*v).Equal.
+func nullableReceiver(value *statement, mode NullableMode) *statement {
+	if mode == NullablePointer {
+		return jparens(value)
+	}
+	return value.Clone()
+}
+
+// generateSQLNullCode defines the conversions between sql.Null* types and pointers, rapidash encodes and decodes the latter.
+func generateSQLNullCode(f *file) {
+	for _, t := range []EntityType{Int32, Int64, Float64, Bool, String, TimeValue} {
+		name := sqlNullName(t)
+		field := sqlNullField(t)
+		nullType := qual("database/sql", name)
+		valueType := valueTypeCode(t, nil)
+		f.Commentf("%sPtr returns the pointer to the value of v, nil for NULL.", name)
+		f.Func().Id(name+"Ptr").Params(i("v").Add(nullType)).Params(ptr(valueType)).Block(
+			ifb(op("!").Id("v").Dot("Valid")).Block(rtn(null())),
+			rtn(addr(i("v").Dot(field))),
+		).Line()
+		f.Commentf("New%s returns %s of the value of v, NULL for nil.", name, name)
+		f.Func().Id("New"+name).Params(i("v").Add(ptr(valueType))).Params(nullType).Block(
+			ifa(i("v"), "==", null()).Block(rtn(nullType.Clone().Values())),
+			rtn(nullType.Clone().Add(vals(cmap{i(field): ptr(i("v")), i("Valid"): bools(true)}))),
+		).Line()
+	}
+}
@@ -0,0 +1,140 @@
+package remodel
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/juju/errors"
+)
+
+// FileWriter receives every rendered file of generators.
+type FileWriter interface {
+	MkdirAll(dir string) error
+	WriteFile(path string, b []byte) error
+	Remove(path string) error
+}
+
+// DiskWriter writes files to disk.
+type DiskWriter struct{}
+
+// DiffWriter does not touch disk and prints unified diff against the files on disk.
+// Paths in the diff are relative to RootDir.
+type DiffWriter struct {
+	Writer  io.Writer
+	RootDir string
+}
+
+// CheckWriter does not touch disk and records paths whose content differs from disk.
+type CheckWriter struct {
+	RootDir string
+	Stale   []string
+}
+
+func (w *DiskWriter) MkdirAll(dir string) error {
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		return nil
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return errors.Trace(err)
+	}
+	log.Printf("create directory: %s", dir)
+	return nil
+}
+
+func (w *DiskWriter) WriteFile(path string, b []byte) error {
+	if err := ioutil.WriteFile(path, b, 0644); err != nil {
+		return errors.Trace(err)
+	}
+	log.Printf("output: %s", path)
+	return nil
+}
+
+func (w *DiskWriter) Remove(path string) error {
+	if err := os.Remove(path); err != nil {
+		return errors.Trace(err)
+	}
+	log.Printf("remove: %s", path)
+	return nil
+}
+
+func (w *DiffWriter) MkdirAll(dir string) error {
+	return nil
+}
+
+func (w *DiffWriter) WriteFile(path string, b []byte) error {
+	current, err := readFileIfExists(path)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	diff := unifiedDiff(relativePath(w.RootDir, path), current, b)
+	if diff == "" {
+		return nil
+	}
+	_, err = fmt.Fprint(w.Writer, diff)
+	return errors.Trace(err)
+}
+
+func (w *DiffWriter) Remove(path string) error {
+	current, err := readFileIfExists(path)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	diff := unifiedDiff(relativePath(w.RootDir, path), current, nil)
+	if diff == "" {
+		return nil
+	}
+	_, err = fmt.Fprint(w.Writer, diff)
+	return errors.Trace(err)
+}
+
+func (w *CheckWriter) MkdirAll(dir string) error {
+	return nil
+}
+
+func (w *CheckWriter) WriteFile(path string, b []byte) error {
+	current, err := readFileIfExists(path)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if current == nil || !bytes.Equal(current, b) {
+		w.Stale = append(w.Stale, relativePath(w.RootDir, path))
+	}
+	return nil
+}
+
+func (w *CheckWriter) Remove(path string) error {
+	w.Stale = append(w.Stale, relativePath(w.RootDir, path))
+	return nil
+}
+
+func relativePath(rootDir, path string) string {
+	root, err := filepath.Abs(rootDir)
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	rel, err := filepath.Rel(root, abs)
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	return filepath.ToSlash(rel)
+}
+
+func readFileIfExists(path string) ([]byte, error) {
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return b, nil
+}
@@ -0,0 +1,74 @@
+package remodel
+
+import (
+	"path/filepath"
+	"sync"
+)
+
+type generatedFile struct {
+	table string
+	layer Layer
+	path  string
+	body  []byte
+}
+
+type renderFunc func(i int) ([]*generatedFile, GenerateErrors)
+
+// renderParallel calls render for 0..n-1 on a bounded worker pool.
+// Files and errors are returned in index order regardless of the completion order.
+func renderParallel(cfg *Config, n int, render renderFunc) ([]*generatedFile, GenerateErrors) {
+	files := make([][]*generatedFile, n)
+	errs := make([]GenerateErrors, n)
+
+	indexes := make(chan int)
+	wg := &sync.WaitGroup{}
+	workers := cfg.workers()
+	if workers > n {
+		workers = n
+	}
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				files[i], errs[i] = render(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	var (
+		allFiles []*generatedFile
+		allErrs  GenerateErrors
+	)
+	for i := 0; i < n; i++ {
+		allFiles = append(allFiles, files[i]...)
+		allErrs = append(allErrs, errs[i]...)
+	}
+	return allFiles, allErrs
+}
+
+// writeGenerated passes rendered files to the writer one by one.
+// Output directories are created on demand.
+func writeGenerated(cfg *Config, files []*generatedFile) GenerateErrors {
+	var errs GenerateErrors
+	dirs := map[string]error{}
+	for _, f := range files {
+		dir := filepath.Dir(f.path)
+		err, exists := dirs[dir]
+		if !exists {
+			err = cfg.Writer.MkdirAll(dir)
+			dirs[dir] = err
+		}
+		if err != nil {
+			errs.add(f.table, f.layer, err)
+			continue
+		}
+		errs.add(f.table, f.layer, cfg.Writer.WriteFile(f.path, f.body))
+	}
+	return errs
+}
@@ -0,0 +1,36 @@
+package remodel
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/yuki-eto/remodel/assert"
+)
+
+func TestRenderParallel(t *testing.T) {
+	cfg := NewConfig("")
+	cfg.Workers = 3
+
+	files, errs := renderParallel(cfg, 10, func(i int) ([]*generatedFile, GenerateErrors) {
+		time.Sleep(time.Duration(10-i) * time.Millisecond)
+		name := fmt.Sprintf("t%d", i)
+		if i%4 == 0 {
+			var errs GenerateErrors
+			errs.add(name, LayerEntity, errors.New("failed"))
+			return nil, errs
+		}
+		return []*generatedFile{{table: name, path: name + ".go"}}, nil
+	})
+
+	var paths []string
+	for _, f := range files {
+		paths = append(paths, f.path)
+	}
+	assert.Equals(t, paths, []string{"t1.go", "t2.go", "t3.go", "t5.go", "t6.go", "t7.go", "t9.go"})
+	assert.Len(t, errs, 3)
+	assert.Equals(t, errs[0].Table, "t0")
+	assert.Equals(t, errs[1].Table, "t4")
+	assert.Equals(t, errs[2].Table, "t8")
+}
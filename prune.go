@@ -0,0 +1,166 @@
+package remodel
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/juju/errors"
+)
+
+// Prune removes generated yaml, entity, proto, dao and model files which have no matching table in s.
+// Codes without the generated code header are never touched, so hand-written codes in the same directories are kept.
+// yaml has no header and is always the output of the ddl, so yaml of a table which is not in the ddl is removed.
+// s must be the whole table set, the table filter is not applied.
+func (s *Tables) Prune(cfg *Config) error {
+	expected, err := s.generatedPaths(cfg)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	orphans, err := orphanedFiles(cfg, expected)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	for _, path := range orphans {
+		if err := cfg.Writer.Remove(path); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
+
+// generatedPaths returns every path which may be generated from the tables.
+func (s *Tables) generatedPaths(cfg *Config) (map[string]struct{}, error) {
+	paths := map[string]struct{}{}
+	structablePath, err := structablePath(cfg)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	paths[structablePath] = struct{}{}
+
+	dir, err := filepath.Abs(yamlDir(cfg))
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	for _, t := range *s {
+		paths[filepath.Join(dir, t.Name+".yml")] = struct{}{}
+		e := &Entity{}
+		e.fromTable(t)
+		d := &Dao{}
+		d.fromTable(t)
+		m := &Model{}
+		m.fromTable(t)
+		for _, f := range []func(*Config) (string, error){e.codePath, e.protoPath, d.codePath, m.codePath} {
+			path, err := f(cfg)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			paths[path] = struct{}{}
+		}
+	}
+	return paths, nil
+}
+
+// orphanedFiles lists generated files in the output directories which are not expected.
+func orphanedFiles(cfg *Config, expected map[string]struct{}) ([]string, error) {
+	type outputDir struct {
+		dir string
+		ext string
+		// noHeader is true for yaml, every file in the directory is generated
+		noHeader bool
+	}
+	entityDir, err := cfg.layerDir(cfg.Entity)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	pd, err := protoDir(cfg)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	daoDir, err := cfg.layerDir(cfg.Dao)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	modelDir, err := cfg.layerDir(cfg.Model)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	dirs := []outputDir{
+		{dir: yamlDir(cfg), ext: ".yml", noHeader: true},
+		{dir: entityDir, ext: ".go"},
+		{dir: pd, ext: ".proto"},
+		{dir: daoDir, ext: ".go"},
+		{dir: modelDir, ext: ".go"},
+	}
+
+	var orphans []string
+	seen := map[string]struct{}{}
+	for _, d := range dirs {
+		// layers may share a directory
+		if _, exists := seen[d.dir]; exists {
+			continue
+		}
+		seen[d.dir] = struct{}{}
+		matches, err := filepath.Glob(filepath.Join(d.dir, "*"+d.ext))
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		sort.Strings(matches)
+		for _, path := range matches {
+			abs, err := filepath.Abs(path)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			if _, exists := expected[abs]; exists {
+				continue
+			}
+			generated := d.noHeader
+			if !generated {
+				generated, err = isGeneratedFile(abs)
+				if err != nil {
+					return nil, errors.Trace(err)
+				}
+			}
+			if generated {
+				orphans = append(orphans, abs)
+			}
+		}
+	}
+	return orphans, nil
+}
+
+// removeYAML removes yaml of the tables which are dropped or renamed by the ddl,
+// otherwise Load brings them back to entity, dao and model.
+func removeYAML(cfg *Config, names []string) GenerateErrors {
+	var errs GenerateErrors
+	for _, name := range names {
+		if !cfg.matchTable(name) {
+			continue
+		}
+		path := filepath.Join(yamlDir(cfg), name+".yml")
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			continue
+		}
+		if err := cfg.Writer.Remove(path); err != nil {
+			errs.add(name, LayerYAML, err)
+		}
+	}
+	return errs
+}
+
+func isGeneratedFile(path string) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	defer f.Close()
+
+	head := make([]byte, len(generatedCodeHeader))
+	if _, err := io.ReadFull(f, head); err != nil {
+		// shorter than the header
+		return false, nil
+	}
+	return bytes.Equal(head, []byte(generatedCodeHeader)), nil
+}
@@ -0,0 +1,293 @@
+package remodel
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/gertd/go-pluralize"
+	"github.com/juju/errors"
+)
+
+// Relation is a reference from columns of the table to columns of another table.
+// It is taken from FOREIGN KEY, or inferred from the name of a `*_id` column.
+type Relation struct {
+	Name       string   `yaml:"name,omitempty"`
+	Columns    []string `yaml:"columns"`
+	RefTable   string   `yaml:"ref_table"`
+	RefColumns []string `yaml:"ref_columns"`
+	IsInferred bool     `yaml:"is_inferred,omitempty"`
+
+	// hasLoader is set by resolveLoaders when model can load the referenced entity by the relation.
+	hasLoader bool
+}
+
+var (
+	foreignKeyRegexp     = regexp.MustCompile("(?is)^(?:constraint(?:\\s+([`\\w]+))?\\s+)?foreign\\s+key(?:\\s+([`\\w]+))?\\s*\\(([^)]*)\\)\\s*references\\s+([`\\w.]+)\\s*\\(([^)]*)\\)")
+	addForeignKeyRegexp  = regexp.MustCompile(`(?is)^add\s+((?:constraint\b.*?\s+)?foreign\s+key\b.*)$`)
+	dropForeignKeyRegexp = regexp.MustCompile("(?is)^drop\\s+(foreign\\s+key|constraint)\\s+([`\\w]+)$")
+)
+
+// parseForeignKey parses a FOREIGN KEY definition, it returns nil when def is not a foreign key.
+func parseForeignKey(def string) *Relation {
+	m := foreignKeyRegexp.FindStringSubmatch(def)
+	if m == nil {
+		return nil
+	}
+	name := m[1]
+	if name == "" {
+		name = m[2]
+	}
+	return &Relation{
+		Name:       unquoteIdent(name),
+		Columns:    splitIdents(m[3]),
+		RefTable:   unquoteIdent(m[4]),
+		RefColumns: splitIdents(m[5]),
+	}
+}
+
+func splitIdents(s string) []string {
+	var idents []string
+	for _, ident := range splitSpecs(s) {
+		idents = append(idents, unquoteIdent(ident))
+	}
+	return idents
+}
+
+// stripForeignKeys removes FOREIGN KEY definitions from CREATE TABLE, which sqlparser cannot parse.
+func stripForeignKeys(stmt string) (string, []*Relation) {
+	open := strings.Index(stmt, "(")
+	if open < 0 {
+		return stmt, nil
+	}
+	end := closingParen(stmt, open)
+	if end < 0 {
+		return stmt, nil
+	}
+	var (
+		defs      []string
+		relations []*Relation
+	)
+	for _, def := range splitSpecs(stmt[open+1 : end]) {
+		if r := parseForeignKey(def); r != nil {
+			relations = append(relations, r)
+			continue
+		}
+		defs = append(defs, def)
+	}
+	if len(relations) == 0 {
+		return stmt, nil
+	}
+	return stmt[:open+1] + strings.Join(defs, ",\n") + stmt[end:], relations
+}
+
+// closingParen returns the index of the parenthesis which closes the one at open, or -1.
+func closingParen(s string, open int) int {
+	var (
+		depth int
+		quote byte
+	)
+	for i := open; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote != '`' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func (t *Table) addRelation(r *Relation) error {
+	for _, c := range r.Columns {
+		if t.column(c) == nil {
+			return errors.Errorf("unknown column %s", c)
+		}
+	}
+	if len(r.Columns) != len(r.RefColumns) {
+		return errors.Errorf("foreign key %s has %d columns but references %d", r.Name, len(r.Columns), len(r.RefColumns))
+	}
+	t.Relations = append(t.Relations, r)
+	return nil
+}
+
+func (t *Table) dropRelation(name string) error {
+	for idx, r := range t.Relations {
+		if !r.IsInferred && strings.EqualFold(r.Name, name) {
+			t.Relations = append(t.Relations[:idx], t.Relations[idx+1:]...)
+			return nil
+		}
+	}
+	return errors.Errorf("unknown foreign key %s", name)
+}
+
+// renameRelationColumn follows RENAME COLUMN and CHANGE in the relations of the table.
+func (t *Table) renameRelationColumn(name, newName string) {
+	for _, r := range t.Relations {
+		for i, c := range r.Columns {
+			if c == name {
+				r.Columns[i] = newName
+			}
+		}
+	}
+}
+
+// dropRelationColumn drops the relations which use the dropped column.
+func (t *Table) dropRelationColumn(name string) {
+	relations := t.Relations[:0]
+	for _, r := range t.Relations {
+		uses := false
+		for _, c := range r.Columns {
+			if c == name {
+				uses = true
+			}
+		}
+		if !uses {
+			relations = append(relations, r)
+		}
+	}
+	t.Relations = relations
+}
+
+// renameRelationTable follows RENAME TABLE in the relations referencing the table.
+func (s Tables) renameRelationTable(name, newName string) {
+	for _, t := range s {
+		for _, r := range t.Relations {
+			if r.RefTable == name {
+				r.RefTable = newName
+			}
+		}
+	}
+}
+
+// resolveRelations infers a relation for each `*_id` column without FOREIGN KEY.
+// `other_user_id` refers to the primary key of `other_users`, or of `users` when the former does not exist.
+// Only a single column primary key of the same type can be referenced.
+func (s Tables) resolveRelations() {
+	p := pluralize.NewClient()
+	for _, t := range s {
+		relations := t.Relations[:0]
+		referenced := map[string]struct{}{}
+		for _, r := range t.Relations {
+			if r.IsInferred {
+				continue
+			}
+			relations = append(relations, r)
+			for _, c := range r.Columns {
+				referenced[c] = struct{}{}
+			}
+		}
+		for _, c := range t.Columns {
+			if _, exists := referenced[c.Name]; exists || !strings.HasSuffix(c.Name, "_id") {
+				continue
+			}
+			words := strings.Split(strings.TrimSuffix(c.Name, "_id"), "_")
+			for k := range words {
+				idx := s.find(p.Plural(strings.Join(words[k:], "_")))
+				if idx < 0 {
+					continue
+				}
+				ref := s[idx]
+				pks := ref.primaryKeyColumns()
+				if len(pks) == 1 && pks[0].EntityType == c.EntityType {
+					relations = append(relations, &Relation{
+						Columns:    []string{c.Name},
+						RefTable:   ref.Name,
+						RefColumns: []string{pks[0].Name},
+						IsInferred: true,
+					})
+				}
+				break
+			}
+		}
+		if len(relations) == 0 {
+			relations = nil
+		}
+		t.Relations = relations
+	}
+}
+
+// resolveLoaders decides which single column relations get association loaders in model.
+// A loader finds the referenced entity by the dao of the referenced table, so the referenced column must be
+// a single column primary key or unique key of the same type, otherwise the dao has no such finder.
+// The relations without loaders are returned as warnings.
+func (s Tables) resolveLoaders() Diagnostics {
+	var ds Diagnostics
+	for _, t := range s {
+		for _, r := range t.Relations {
+			r.hasLoader = false
+			if len(r.Columns) != 1 || len(r.RefColumns) != 1 {
+				continue
+			}
+			c := t.column(r.Columns[0])
+			if c == nil || c.EntityType == TimePtr || c.EntityType == ByteSlice || c.EntityType == StringSlice || c.isDecimal() || c.GoType != "" {
+				continue
+			}
+			if err := s.loaderRef(c, r); err != nil {
+				d := t.diagnostic(SeverityWarning, c.Name, errors.Annotate(err, "no loader in model"))
+				ds = append(ds, d)
+				continue
+			}
+			r.hasLoader = true
+		}
+	}
+	return ds
+}
+
+// referrers returns the names of the tables which have relations to any of names.
+// Their loaders depend on the keys of the referenced tables, so they are regenerated together.
+func (s Tables) referrers(names map[string]struct{}) map[string]struct{} {
+	found := map[string]struct{}{}
+	for _, t := range s {
+		for _, r := range t.Relations {
+			if _, exists := names[r.RefTable]; exists {
+				found[t.Name] = struct{}{}
+			}
+		}
+	}
+	return found
+}
+
+// loaderRef returns why the dao of the referenced table cannot find a single entity by the value of c.
+func (s Tables) loaderRef(c *Column, r *Relation) error {
+	idx := s.find(r.RefTable)
+	if idx < 0 {
+		return errors.Errorf("referenced table %s does not exist", r.RefTable)
+	}
+	ref := s[idx]
+	refColumn := ref.column(r.RefColumns[0])
+	if refColumn == nil {
+		return errors.Errorf("referenced column %s.%s does not exist", ref.Name, r.RefColumns[0])
+	}
+	// dao of user tables takes user_id from userIDGetter, so it has no finder by user_id
+	if refColumn.Name == "user_id" {
+		return errors.Errorf("dao of %s does not find by user_id", ref.Name)
+	}
+	isKey := false
+	for _, index := range ref.Indexes {
+		if (index.IsPrimaryKey || index.IsUnique) && len(index.Columns) == 1 && index.Columns[0] == refColumn.Name {
+			isKey = true
+		}
+	}
+	if !isKey {
+		return errors.Errorf("%s.%s is neither a single column primary key nor unique key", ref.Name, refColumn.Name)
+	}
+	if !refColumn.IsNotNull && !refColumn.IsPrimaryKey {
+		return errors.Errorf("%s.%s is nullable", ref.Name, refColumn.Name)
+	}
+	if refColumn.EntityType != c.EntityType || len(refColumn.EnumValues) > 0 || refColumn.isDecimal() || refColumn.GoType != "" {
+		return errors.Errorf("type of %s.%s does not match", ref.Name, refColumn.Name)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package remodel
+
+import (
+	"github.com/juju/errors"
+)
+
+// Render parses a ddl and returns the source of the layer of the first CREATE TABLE.
+// The other CREATE TABLE statements are only the tables referred by the relations of the first one.
+// It neither reads nor writes files under cfg.RootDir, so editors and pipelines can use it as a pure function.
+func Render(cfg *Config, ddl []byte, layer Layer) ([]byte, error) {
+	ts := Tables{}
+	errs := ts.parseDDL(string(ddl))
+	ts.resolveRelations()
+	ts.resolveLoaders()
+	for _, d := range ts.lint(cfg) {
+		errs.add(d.Table, LayerYAML, d)
+	}
+	// warnings are left to generate and lint
+	if errs, _ = errs.split(); len(errs) > 0 {
+		return nil, errs
+	}
+	ts.resolveConfig(cfg)
+	if len(ts) == 0 {
+		return nil, errors.New("expected CREATE TABLE, found none")
+	}
+	t := ts[0]
+
+	var (
+		f   *generatedFile
+		err error
+	)
+	switch layer {
+	case LayerYAML:
+		f, err = t.render("")
+	case LayerEntity:
+		e := &Entity{}
+		e.fromTable(t)
+		f, err = e.renderCode(cfg)
+	case LayerProto:
+		e := &Entity{}
+		e.fromTable(t)
+		f, err = e.renderProtoBuf(cfg)
+	case LayerDao:
+		if cfg.Module == "" {
+			return nil, errors.New("module name is required")
+		}
+		d := &Dao{}
+		d.fromTable(t)
+		f, err = d.render(cfg)
+	case LayerModel:
+		if cfg.Module == "" {
+			return nil, errors.New("module name is required")
+		}
+		m := &Model{}
+		m.fromTable(t)
+		f, err = m.render(cfg)
+	default:
+		return nil, errors.Errorf("unknown layer: %s", layer)
+	}
+	if err != nil {
+		return nil, errors.Annotatef(err, "cannot render %s of %s", layer, t.Name)
+	}
+	return f.body, nil
+}
@@ -0,0 +1,686 @@
+package remodel
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/yuki-eto/remodel/assert"
+	"gopkg.in/yaml.v2"
+)
+
+func TestRender(t *testing.T) {
+	ddl := []byte(`
+CREATE TABLE user_items (
+  id BIGINT(20) UNSIGNED NOT NULL AUTO_INCREMENT,
+  user_id BIGINT(20) UNSIGNED NOT NULL,
+  PRIMARY KEY (id),
+  KEY idx_user_id (user_id)
+);`)
+	cfg := NewConfig("")
+	cfg.Module = "example"
+
+	for layer, want := range map[Layer]string{
+		LayerYAML:   "name: user_items\n",
+		LayerEntity: "type UserItem struct {",
+		LayerProto:  "message UserItemEntity {",
+		LayerDao:    "type UserItem interface {",
+		LayerModel:  "type UserItemImpl struct {",
+	} {
+		b, err := Render(cfg, ddl, layer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.True(t, strings.Contains(string(b), want))
+	}
+
+	_, err := Render(cfg, ddl, Layer("unknown"))
+	assert.NotEquals(t, err, nil)
+	_, err = Render(NewConfig(""), ddl, LayerDao)
+	assert.NotEquals(t, err, nil)
+	_, err = Render(cfg, []byte("CREATE TABLE"), LayerEntity)
+	assert.NotEquals(t, err, nil)
+}
+
+func TestRender_compositePrimaryKey(t *testing.T) {
+	ddl := []byte(`
+CREATE TABLE user_items (
+  user_id BIGINT(20) UNSIGNED NOT NULL,
+  item_id INT(10) UNSIGNED NOT NULL,
+  count INT(10) UNSIGNED NOT NULL,
+  created_at DATETIME,
+  updated_at DATETIME,
+  PRIMARY KEY (user_id, item_id)
+);`)
+	cfg := NewConfig("")
+	cfg.Module = "example"
+
+	b, err := Render(cfg, ddl, LayerYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equals(t, strings.Count(string(b), "is_primary_key: true"), 3)
+
+	b, err = Render(cfg, ddl, LayerEntity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.False(t, strings.Contains(string(b), "e.ID"))
+
+	b, err = Render(cfg, ddl, LayerDao)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"FindByPrimaryKey(k0 uint32) (*entity.UserItem, error)",
+		`b := d.qb().Eq("user_id", e.UserID).Eq("item_id", e.ItemID)`,
+		"if current.UserID == 0 {",
+		"if e.UserID == 0 || e.ItemID == 0 {",
+	} {
+		assert.True(t, strings.Contains(string(b), want))
+	}
+	assert.False(t, strings.Contains(string(b), "e.ID"))
+
+	b, err = Render(cfg, ddl, LayerModel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, strings.Contains(string(b), "FindByPrimaryKey(userID uint64, itemID uint32) *UserItemInstance"))
+	assert.False(t, strings.Contains(string(b), "FindByID("))
+}
+
+func TestRender_primaryKeyName(t *testing.T) {
+	cfg := NewConfig("")
+	cfg.Module = "example"
+
+	t.Run("varchar", func(t *testing.T) {
+		ddl := []byte(`
+CREATE TABLE user_coupons (
+  code VARCHAR(32) NOT NULL,
+  user_id BIGINT(20) UNSIGNED NOT NULL,
+  created_at DATETIME,
+  updated_at DATETIME,
+  PRIMARY KEY (code)
+);`)
+		b, err := Render(cfg, ddl, LayerEntity)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.False(t, strings.Contains(string(b), "e.ID"))
+		assert.False(t, strings.Contains(string(b), `if e.Code != "" {`))
+
+		b, err = Render(cfg, ddl, LayerDao)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, want := range []string{
+			"FindByCode(k0 string) (*entity.UserCoupon, error)",
+			`b := d.qb().Eq("code", e.Code)`,
+			`if current.Code == "" {`,
+			`if e.Code == "" {`,
+		} {
+			assert.True(t, strings.Contains(string(b), want))
+		}
+		assert.False(t, strings.Contains(string(b), "e.ID"))
+
+		b, err = Render(cfg, ddl, LayerModel)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.True(t, strings.Contains(string(b), "FindByCode(code string) *UserCouponInstance"))
+		assert.False(t, strings.Contains(string(b), "FindByID("))
+	})
+
+	t.Run("signed auto increment", func(t *testing.T) {
+		ddl := []byte(`
+CREATE TABLE user_stages (
+  stage_id INT(10) NOT NULL AUTO_INCREMENT,
+  user_id BIGINT(20) UNSIGNED NOT NULL,
+  created_at DATETIME,
+  updated_at DATETIME,
+  PRIMARY KEY (stage_id)
+);`)
+		b, err := Render(cfg, ddl, LayerEntity)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.True(t, strings.Contains(string(b), `enc.Int32("stage_id", e.StageID)`))
+		assert.False(t, strings.Contains(string(b), "e.ID"))
+
+		b, err = Render(cfg, ddl, LayerDao)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, want := range []string{
+			"if e.StageID == 0 {",
+			"e.StageID = int32(id)",
+			`b := d.qb().Eq("stage_id", e.StageID)`,
+		} {
+			assert.True(t, strings.Contains(string(b), want))
+		}
+		assert.False(t, strings.Contains(string(b), `"stage_id": e.StageID`))
+
+		b, err = Render(cfg, ddl, LayerModel)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.True(t, strings.Contains(string(b), "FindByStageID(stageID int32) *UserStageInstance"))
+	})
+}
+
+func TestRender_enum(t *testing.T) {
+	ddl := []byte(`
+CREATE TABLE user_cards (
+  id BIGINT(20) UNSIGNED NOT NULL AUTO_INCREMENT,
+  user_id BIGINT(20) UNSIGNED NOT NULL,
+  rarity ENUM('R', 'SR', 'SSR', 'it''s') NOT NULL,
+  PRIMARY KEY (id),
+  KEY rarity (rarity)
+);`)
+	cfg := NewConfig("")
+	cfg.Module = "example"
+
+	b, err := Render(cfg, ddl, LayerYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, strings.Contains(string(b), "enum_values:\n  - R\n  - SR\n  - SSR\n  - it's\n"))
+
+	b, err = Render(cfg, ddl, LayerEntity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"Rarity UserCardRarity",
+		"type UserCardRarity string",
+		`UserCardRaritySSR UserCardRarity = "SSR"`,
+		`UserCardRarityIts UserCardRarity = "it's"`,
+		"func (t UserCardRarity) IsValid() bool {",
+		"func ParseUserCardRarity(s string) (UserCardRarity, error) {",
+		`e.Rarity = UserCardRarity(dec.String("rarity"))`,
+		`enc.String("rarity", string(e.Rarity))`,
+	} {
+		assert.True(t, strings.Contains(string(b), want))
+	}
+
+	b, err = Render(cfg, ddl, LayerDao)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"FindByRarity(k0 entity.UserCardRarity) (entity.UserCards, error)",
+		"FindByRarities(k0 []entity.UserCardRarity) (entity.UserCards, error)",
+		`b := d.qb().Eq("rarity", string(k0))`,
+		`b := d.qb().In("rarity", values)`,
+		`"rarity": string(e.Rarity)`,
+	} {
+		assert.True(t, strings.Contains(string(b), want))
+	}
+
+	b, err = Render(cfg, ddl, LayerModel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, strings.Contains(string(b), "FilterByRarity(c entity.UserCardRarity) *UserCardsInstance"))
+
+	_, err = Render(cfg, []byte("CREATE TABLE user_cards (id BIGINT(20) UNSIGNED NOT NULL, kind ENUM('a-b', 'a b') NOT NULL, PRIMARY KEY (id));"), LayerEntity)
+	assert.True(t, err != nil)
+}
+
+func TestRender_set(t *testing.T) {
+	ddl := []byte(`
+CREATE TABLE user_cards (
+  id BIGINT(20) UNSIGNED NOT NULL AUTO_INCREMENT,
+  user_id BIGINT(20) UNSIGNED NOT NULL,
+  tags SET('one', 'two') NOT NULL,
+  PRIMARY KEY (id)
+);`)
+	cfg := NewConfig("")
+	cfg.Module = "example"
+
+	b, err := Render(cfg, ddl, LayerYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, strings.Contains(string(b), "enum_values:\n  - one\n  - two\n"))
+
+	b, err = Render(cfg, ddl, LayerEntity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"Tags   UserCardTags",
+		"type UserCardTags []string",
+		`UserCardTagsOne = "one"`,
+		"func (s UserCardTags) Has(member string) bool {",
+		"func (s *UserCardTags) Add(member string) error {",
+		"func (s *UserCardTags) Remove(member string) {",
+		"func (s UserCardTags) IsValid() bool {",
+		`e.Tags = UserCardTags(dec.Strings("tags"))`,
+		`enc.Strings("tags", []string(e.Tags))`,
+	} {
+		assert.True(t, strings.Contains(string(b), want))
+	}
+
+	b, err = Render(cfg, ddl, LayerModel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, strings.Contains(string(b), "FilterByTags(c entity.UserCardTags) *UserCardsInstance"))
+	assert.True(t, strings.Contains(string(b), "FilterByTagsContaining(member string) *UserCardsInstance"))
+}
+
+func TestRender_comment(t *testing.T) {
+	ddl := []byte(`
+CREATE TABLE user_cards (
+  id BIGINT(20) UNSIGNED NOT NULL AUTO_INCREMENT COMMENT 'card id',
+  user_id BIGINT(20) UNSIGNED NOT NULL,
+  rarity TINYINT(3) UNSIGNED NOT NULL COMMENT 'rarity of the card',
+  PRIMARY KEY (id),
+  KEY rarity (rarity)
+) COMMENT='cards of the user';`)
+	cfg := NewConfig("")
+	cfg.Module = "example"
+
+	b, err := Render(cfg, ddl, LayerEntity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, strings.Contains(string(b), "// cards of the user\ntype UserCard struct {\n\t// card id\n\tID     uint64\n"))
+	assert.True(t, strings.Contains(string(b), "\t// rarity of the card\n\tRarity uint8\n"))
+
+	b, err = Render(cfg, ddl, LayerProto)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, strings.Contains(string(b), "// cards of the user\nmessage UserCardEntity {\n  // card id\n  uint64 id = 1;\n"))
+
+	b, err = Render(cfg, ddl, LayerDao)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, strings.Contains(string(b), "// cards of the user\ntype UserCard interface {"))
+	assert.True(t, strings.Contains(string(b), "\t// FindByRarity finds by rarity (rarity of the card).\n\tFindByRarity(k0 uint8) (entity.UserCards, error)\n"))
+}
+
+func TestRender_relation(t *testing.T) {
+	ddl := []byte(`
+CREATE TABLE user_gifts (
+  id BIGINT(20) UNSIGNED NOT NULL AUTO_INCREMENT,
+  sender BIGINT(20) UNSIGNED NOT NULL,
+  item_id INT(10) UNSIGNED NOT NULL,
+  group_code VARCHAR(40) NOT NULL,
+  account_id BIGINT(20) UNSIGNED NOT NULL,
+  PRIMARY KEY (id),
+  FOREIGN KEY (sender) REFERENCES users (id),
+  CONSTRAINT fk_item FOREIGN KEY (item_id) REFERENCES items (id),
+  CONSTRAINT fk_group FOREIGN KEY (group_code) REFERENCES ` + "`groups`" + ` (code),
+  CONSTRAINT fk_account FOREIGN KEY (account_id) REFERENCES accounts (id)
+);
+CREATE TABLE users (
+  id BIGINT(20) UNSIGNED NOT NULL AUTO_INCREMENT,
+  created_at DATETIME NOT NULL,
+  updated_at DATETIME NOT NULL,
+  PRIMARY KEY (id)
+);
+CREATE TABLE items (
+  id INT(10) UNSIGNED NOT NULL,
+  PRIMARY KEY (id)
+);
+CREATE TABLE ` + "`groups`" + ` (
+  id INT(10) UNSIGNED NOT NULL,
+  code VARCHAR(40) NOT NULL,
+  PRIMARY KEY (id),
+  KEY idx_code (code)
+);`)
+	cfg := NewConfig("")
+	cfg.Module = "example"
+
+	b, err := Render(cfg, ddl, LayerYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, strings.Contains(string(b), "relations:\n- columns:\n  - sender\n  ref_table: users\n"))
+
+	b, err = Render(cfg, ddl, LayerModel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"func (i *UserGiftInstance) SenderUser(d dao.User) (*UserInstance, error) {",
+		"e, err := d.FindByID(i.Sender)",
+		"return &UserInstance{\n\t\tUser:    e,\n\t\tuserDao: d,\n\t}, nil",
+		"func (i *UserGiftInstance) Item(d dao.Item) (*ItemInstance, error) {",
+		"return &ItemInstance{Item: e}, nil",
+		"func (i *UserGiftsInstance) SenderUsers(d dao.User) (*UsersInstance, error) {",
+		"func (i *UserGiftsInstance) Items(d dao.Item) (*ItemsInstance, error) {",
+		"keys := []uint32{}",
+		"es, err := d.FindByIDs(keys)",
+	} {
+		assert.True(t, strings.Contains(string(b), want))
+	}
+	// FindByCode of groups returns a slice, and dao.Account does not exist
+	for _, unwanted := range []string{"GroupCodeGroup", "FindByCode", "dao.Group", "Account(", "dao.Account"} {
+		assert.False(t, strings.Contains(string(b), unwanted))
+	}
+
+	ts := Tables{}
+	assert.Len(t, ts.parseDDL(string(ddl)), 0)
+	ts.resolveRelations()
+	ds := ts.resolveLoaders()
+	assert.Len(t, ds, 2)
+	assert.Equals(t, ds[0].Error(), "6:3: warning: user_gifts.group_code: no loader in model: groups.code is neither a single column primary key nor unique key")
+	assert.Equals(t, ds[1].Error(), "7:3: warning: user_gifts.account_id: no loader in model: referenced table accounts does not exist")
+
+	var loaders []string
+	for _, r := range ts[0].Relations {
+		if r.hasLoader {
+			loaders = append(loaders, r.Columns[0])
+		}
+	}
+	assert.Equals(t, loaders, []string{"sender", "item_id"})
+}
+
+func TestRender_nullable(t *testing.T) {
+	ddl := `
+CREATE TABLE user_cards (
+  id BIGINT(20) UNSIGNED NOT NULL AUTO_INCREMENT,
+  user_id BIGINT(20) UNSIGNED NOT NULL,
+  rank INT(10),
+  nickname VARCHAR(32),
+  expired_at DATETIME,
+  score BIGINT(20) UNSIGNED,
+  created_at DATETIME NOT NULL,
+  updated_at DATETIME NOT NULL,
+  PRIMARY KEY (id),
+  KEY rank (rank)
+);`
+	for _, tc := range []struct {
+		name     string
+		nullable NullableMode
+		wants    map[Layer][]string
+	}{
+		{
+			name: "none",
+			wants: map[Layer][]string{
+				LayerEntity: {
+					"Rank      int32",
+					"Nickname  string",
+					"CreatedAt *time.Time",
+					`e.Rank = dec.Int32("rank")`,
+				},
+			},
+		},
+		{
+			name:     "pointer",
+			nullable: NullablePointer,
+			wants: map[Layer][]string{
+				LayerEntity: {
+					"Rank      *int32",
+					"Nickname  *string",
+					"ExpiredAt *time.Time",
+					"CreatedAt time.Time",
+					`enc.Int32Ptr("rank", e.Rank)`,
+					`e.Rank = dec.Int32Ptr("rank")`,
+					`s.FieldInt32("rank")`,
+					`e.CreatedAt = dec.Time("created_at")`,
+				},
+				LayerDao: {
+					"FindByRank(k0 int32) (entity.UserCards, error)",
+					`"rank":       e.Rank,`,
+					"e.CreatedAt = now",
+				},
+				LayerModel: {
+					"func (i *UserCardsInstance) FilterByRank(c int32) *UserCardsInstance {",
+					"if v.Rank != nil && *v.Rank == c {",
+					"return s.values[j].Rank != nil && (s.values[i].Rank == nil || *s.values[i].Rank < *s.values[j].Rank)",
+					"if v.ExpiredAt != nil && (*v.ExpiredAt).Equal(c) {",
+					"func (i *UserCardsInstance) Ranks() []*int32 {",
+				},
+			},
+		},
+		{
+			name:     "sql",
+			nullable: NullableSQL,
+			wants: map[Layer][]string{
+				LayerEntity: {
+					"Rank      sql.NullInt32",
+					"ExpiredAt sql.NullTime",
+					"CreatedAt time.Time",
+					`enc.Int32Ptr("rank", NullInt32Ptr(e.Rank))`,
+					`e.Rank = NewNullInt32(dec.Int32Ptr("rank"))`,
+					// sql.NullInt64 overflows over math.MaxInt64
+					"Score     *uint64",
+					`enc.Uint64Ptr("score", e.Score)`,
+					`e.Score = dec.Uint64Ptr("score")`,
+				},
+				LayerDao: {
+					"FindByRank(k0 int32) (entity.UserCards, error)",
+					`"rank":       entity.NullInt32Ptr(e.Rank),`,
+				},
+				LayerModel: {
+					"func (i *UserCardsInstance) FilterByRank(c int32) *UserCardsInstance {",
+					"if v.Rank.Valid && v.Rank.Int32 == c {",
+					"return s.values[j].Rank.Valid && (!s.values[i].Rank.Valid || s.values[i].Rank.Int32 < s.values[j].Rank.Int32)",
+					"if v.ExpiredAt.Valid && v.ExpiredAt.Time.Equal(c) {",
+					"if v.Score != nil && *v.Score == c {",
+				},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := NewConfig("")
+			cfg.Module = "example"
+			cfg.Nullable = tc.nullable
+			for layer, wants := range tc.wants {
+				assertLines(t, layer, renderLayer(t, cfg, ddl, layer), wants...)
+			}
+		})
+	}
+}
+
+func TestRender_json(t *testing.T) {
+	ddl := `
+CREATE TABLE user_states (
+  id BIGINT(20) UNSIGNED NOT NULL AUTO_INCREMENT,
+  user_id BIGINT(20) UNSIGNED NOT NULL,
+  state JSON,
+  PRIMARY KEY (id)
+);`
+	cfg := NewConfig("")
+	cfg.Module = "example"
+	cfg.JSON = true
+
+	var table *Table
+	if err := yaml.Unmarshal([]byte(renderLayer(t, cfg, ddl, LayerYAML)), &table); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equals(t, table.column("state").EntityType, RawJSON)
+
+	assertLines(t, LayerEntity, renderLayer(t, cfg, ddl, LayerEntity),
+		"State  json.RawMessage",
+		`enc.Bytes("state", []byte(e.State))`,
+		`e.State = json.RawMessage(dec.Bytes("state"))`,
+		`s.FieldBytes("state")`,
+		`"state": e.State,`,
+	)
+
+	b := renderLayer(t, cfg, ddl, LayerModel)
+	assertLines(t, LayerModel, b, "func (i *UserStatesInstance) States() []json.RawMessage {")
+	if strings.Contains(b, "FilterByState") {
+		t.Error("model must not filter by JSON")
+	}
+}
+
+func TestRender_decimal(t *testing.T) {
+	ddl := `
+CREATE TABLE user_wallets (
+  id BIGINT(20) UNSIGNED NOT NULL AUTO_INCREMENT,
+  user_id BIGINT(20) UNSIGNED NOT NULL,
+  balance DECIMAL(20,4) NOT NULL,
+  bonus DECIMAL(20,4),
+  PRIMARY KEY (id)
+);`
+	cfg := NewConfig("")
+	cfg.Module = "example"
+	cfg.JSON = true
+	cfg.Nullable = NullablePointer
+
+	var table *Table
+	if err := yaml.Unmarshal([]byte(renderLayer(t, cfg, ddl, LayerYAML)), &table); err != nil {
+		t.Fatal(err)
+	}
+	balance := table.column("balance")
+	assert.Equals(t, balance.EntityType, String)
+	assert.Equals(t, balance.Size, uint64(20))
+	assert.Equals(t, balance.Scale, uint64(4))
+
+	t.Run("string", func(t *testing.T) {
+		assertLines(t, LayerEntity, renderLayer(t, cfg, ddl, LayerEntity),
+			"Balance string",
+			"Bonus   *string",
+			`enc.String("balance", e.Balance)`,
+			`e.Balance = dec.String("balance")`,
+		)
+		assertLines(t, LayerProto, renderLayer(t, cfg, ddl, LayerProto), "string balance = 2;")
+		b := renderLayer(t, cfg, ddl, LayerModel)
+		assertLines(t, LayerModel, b, "func (i *UserWalletsInstance) FilterByBalance(c string) *UserWalletsInstance {")
+		if strings.Contains(b, "SortByBalance") {
+			t.Error("strings of decimals must not be sorted")
+		}
+	})
+
+	t.Run("type", func(t *testing.T) {
+		cfg := *cfg
+		cfg.Decimal = "github.com/shopspring/decimal.Decimal"
+		// NULL has no value of the type, so the nullable column stays a string
+		assertLines(t, LayerEntity, renderLayer(t, &cfg, ddl, LayerEntity),
+			"Balance decimal.Decimal",
+			"Bonus   *string",
+			`balanceText, err := e.Balance.MarshalText()`,
+			`enc.String("balance", string(balanceText))`,
+			`enc.StringPtr("bonus", e.Bonus)`,
+			`if s := dec.String("balance"); s != "" {`,
+			`if err := e.Balance.UnmarshalText([]byte(s)); err != nil {`,
+		)
+		assertLines(t, LayerDao, renderLayer(t, &cfg, ddl, LayerDao),
+			`"balance": string(balanceText),`,
+			`"bonus":   e.Bonus,`,
+		)
+		b := renderLayer(t, &cfg, ddl, LayerModel)
+		assertLines(t, LayerModel, b, "func (i *UserWalletsInstance) Balances() []decimal.Decimal {")
+		if strings.Contains(b, "FilterByBalance") {
+			t.Error("decimal type must not be compared by ==")
+		}
+	})
+
+	t.Run("pointer", func(t *testing.T) {
+		cfg := *cfg
+		cfg.Decimal = "*github.com/shopspring/decimal.Decimal"
+		// nil is encoded as NULL, and NULL is decoded as nil
+		assertLines(t, LayerEntity, renderLayer(t, &cfg, ddl, LayerEntity),
+			"Bonus   *decimal.Decimal",
+			"var bonusText *string",
+			"if e.Bonus != nil {",
+			"b, err := e.Bonus.MarshalText()",
+			"bonusText = &s",
+			`enc.StringPtr("bonus", bonusText)`,
+			`if s := dec.StringPtr("bonus"); s != nil {`,
+			"v := new(decimal.Decimal)",
+			"if err := v.UnmarshalText([]byte(*s)); err != nil {",
+			"e.Bonus = v",
+		)
+		assertLines(t, LayerDao, renderLayer(t, &cfg, ddl, LayerDao),
+			"var bonusText *string",
+			`"bonus":   bonusText,`,
+		)
+	})
+
+	t.Run("go_type", func(t *testing.T) {
+		c := *table.column("bonus")
+		c.GoType = "*example/decimal.Decimal"
+		assert.Equals(t, c.validateGoType(), nil)
+		c.GoType = "example/decimal.Decimal"
+		assert.NotEquals(t, c.validateGoType(), nil)
+	})
+}
+
+func TestRender_generated(t *testing.T) {
+	ddl := `
+CREATE TABLE user_profiles (
+  id BIGINT(20) UNSIGNED NOT NULL AUTO_INCREMENT,
+  user_id BIGINT(20) UNSIGNED NOT NULL,
+  first_name VARCHAR(40) NOT NULL,
+  last_name VARCHAR(40) NOT NULL,
+  full_name VARCHAR(81) GENERATED ALWAYS AS (concat(first_name, ' ', last_name)) VIRTUAL NOT NULL COMMENT 'known as (first last)',
+  name_length INT AS (char_length(first_name) + char_length(last_name)) STORED,
+  created_at DATETIME NOT NULL,
+  updated_at DATETIME NOT NULL,
+  PRIMARY KEY (id),
+  KEY idx_full_name (full_name)
+);`
+	cfg := NewConfig("")
+	cfg.Module = "example"
+
+	var table *Table
+	if err := yaml.Unmarshal([]byte(renderLayer(t, cfg, ddl, LayerYAML)), &table); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equals(t, table.column("full_name").Comment, "known as (first last)")
+	for name, generated := range map[string]bool{"last_name": false, "full_name": true, "name_length": true} {
+		if table.column(name).IsGenerated != generated {
+			t.Errorf("is_generated of %s must be %v", name, generated)
+		}
+	}
+
+	// generated columns are decoded, but never encoded nor saved
+	entity := renderLayer(t, cfg, ddl, LayerEntity)
+	assertLines(t, LayerEntity, entity,
+		`enc.String("last_name", e.LastName)`,
+		`e.FullName = dec.String("full_name")`,
+		`e.NameLength = dec.Int32("name_length")`,
+		`s.FieldString("full_name")`,
+	)
+	dao := renderLayer(t, cfg, ddl, LayerDao)
+	assertLines(t, LayerDao, dao,
+		"FindByFullName(k0 string) (entity.UserProfiles, error)",
+		`"last_name":  e.LastName,`,
+	)
+	for _, u := range []string{`enc.String("full_name"`, `enc.Int32("name_length"`} {
+		if strings.Contains(entity, u) {
+			t.Errorf("entity must not encode a generated column: %s", u)
+		}
+	}
+	for _, u := range []string{`"full_name":`, `"name_length":`} {
+		if strings.Contains(dao, u) {
+			t.Errorf("dao must not save a generated column: %s", u)
+		}
+	}
+
+	assertLines(t, LayerModel, renderLayer(t, cfg, ddl, LayerModel),
+		"func (i *UserProfilesInstance) FilterByFullName(c string) *UserProfilesInstance {",
+	)
+}
+
+// renderLayer renders the layer of ddl, the test fails on an error.
+func renderLayer(t *testing.T, cfg *Config, ddl string, layer Layer) string {
+	t.Helper()
+	b, err := Render(cfg, []byte(ddl), layer)
+	if err != nil {
+		t.Fatalf("cannot render %s: %s", layer, err)
+	}
+	return string(b)
+}
+
+// assertLines fails unless every line of wants is in code, regardless of the indent.
+func assertLines(t *testing.T, layer Layer, code string, wants ...string) {
+	t.Helper()
+	lines := map[string]struct{}{}
+	for _, line := range strings.Split(code, "\n") {
+		lines[strings.TrimSpace(line)] = struct{}{}
+	}
+	for _, want := range wants {
+		if _, exists := lines[want]; !exists {
+			t.Errorf("%s does not have the line %q", layer, want)
+		}
+	}
+}
@@ -1,15 +1,16 @@
 package remodel
 
 import (
+	"bytes"
 	"fmt"
 	"io/ioutil"
-	"log"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 
-	"github.com/gertd/go-pluralize"
 	"github.com/juju/errors"
 	"github.com/xwb1989/sqlparser"
 	"gopkg.in/yaml.v2"
@@ -18,26 +19,48 @@ import (
 type ColumnType string
 
 type Table struct {
-	Name       string    `yaml:"name"`
-	Columns    []*Column `yaml:"columns"`
-	Indexes    []*Index  `yaml:"indexes"`
-	IsReadOnly bool      `yaml:"is_read_only"`
+	Name       string      `yaml:"name"`
+	Comment    string      `yaml:"comment,omitempty"`
+	Charset    string      `yaml:"charset,omitempty"`
+	Collation  string      `yaml:"collation,omitempty"`
+	Columns    []*Column   `yaml:"columns"`
+	Indexes    []*Index    `yaml:"indexes"`
+	Relations  []*Relation `yaml:"relations,omitempty"`
+	IsReadOnly bool        `yaml:"is_read_only"`
+
+	// path is the ddl file which creates the table, empty when it is not parsed from a file.
+	path string
+	// src is the ddl which creates the table, and CREATE TABLE is src[start:end].
+	src        string
+	start, end int
 }
 
 type Tables []*Table
 
 type Column struct {
 	Name            string     `yaml:"name"`
+	Comment         string     `yaml:"comment,omitempty"`
 	ColumnType      ColumnType `yaml:"column_type"`
 	EntityType      EntityType `yaml:"entity_type"`
+	GoType          string     `yaml:"go_type,omitempty"`
 	Size            uint64     `yaml:"size"`
+	Scale           uint64     `yaml:"scale,omitempty"`
 	IsAutoIncrement bool       `yaml:"is_auto_increment"`
+	IsGenerated     bool       `yaml:"is_generated,omitempty"`
 	IsUnsigned      bool       `yaml:"is_unsigned"`
 	IsNotNull       bool       `yaml:"is_not_null"`
 	DefaultValue    string     `yaml:"default_value"`
+	OnUpdate        string     `yaml:"on_update,omitempty"`
 	IsPrimaryKey    bool       `yaml:"is_primary_key"`
 	UniqueIndexKeys []string   `yaml:"unique_index_keys"`
 	IndexKeys       []string   `yaml:"index_keys"`
+	EnumValues      []string   `yaml:"enum_values,omitempty"`
+	Charset         string     `yaml:"charset,omitempty"`
+	Collation       string     `yaml:"collation,omitempty"`
+	// Nullable is the nullable mode of the project, which is given on generate.
+	Nullable NullableMode `yaml:"-"`
+	// Decimal is the decimal type of the project, which is given on generate.
+	Decimal string `yaml:"-"`
 }
 
 type Index struct {
@@ -47,20 +70,43 @@ type Index struct {
 	Columns      []string `yaml:"columns"`
 }
 
-func (s *Tables) Output(rootDir string) error {
-	schemaDir := filepath.Join(rootDir, "schema")
-	sqlDir := filepath.Join(schemaDir, "sql")
-	if _, err := os.Stat(sqlDir); os.IsNotExist(err) {
+func (s *Tables) Output(cfg *Config) error {
+	paths, err := sqlFiles(cfg)
+	if err != nil {
 		return errors.Trace(err)
 	}
-	yamlDir := filepath.Join(schemaDir, "yaml")
-	if _, err := os.Stat(yamlDir); os.IsNotExist(err) {
-		if err := os.Mkdir(yamlDir, 0755); err != nil {
+	removed, errs := s.parseAndLint(cfg, paths)
+	if len(errs) > 0 {
+		return errs
+	}
+	dir := yamlDir(cfg)
+	if errs := s.keepGoTypes(dir); len(errs) > 0 {
+		return errs
+	}
+
+	var files []*generatedFile
+	for _, t := range *s {
+		if !cfg.matchTable(t.Name) {
+			continue
+		}
+		f, err := t.render(dir)
+		if err != nil {
 			return errors.Trace(err)
 		}
-		log.Printf("create directory: %s", yamlDir)
+		files = append(files, f)
 	}
 
+	errs = writeGenerated(cfg, files)
+	return append(errs, removeYAML(cfg, removed)...).orNil()
+}
+
+func sqlFiles(cfg *Config) ([]string, error) {
+	sqlDir := filepath.Join(cfg.RootDir, "schema", "sql")
+	if _, err := os.Stat(sqlDir); os.IsNotExist(err) {
+		return nil, errors.Trace(err)
+	}
+
+	var paths []string
 	if err := filepath.Walk(sqlDir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return errors.Trace(err)
@@ -71,64 +117,216 @@ func (s *Tables) Output(rootDir string) error {
 		if filepath.Ext(path) != ".sql" {
 			return nil
 		}
-		b, err := ioutil.ReadFile(path)
-		if err != nil {
-			return errors.Trace(err)
-		}
-		t := &Table{}
-		if err := t.parse(string(b)); err != nil {
-			return errors.Trace(err)
-		}
-		*s = append(*s, t)
+		paths = append(paths, path)
 		return nil
 	}); err != nil {
-		return errors.Trace(err)
+		return nil, errors.Trace(err)
+	}
+	return paths, nil
+}
+
+func yamlDir(cfg *Config) string {
+	return filepath.Join(cfg.RootDir, "schema", "yaml")
+}
+
+// parseFiles applies the ddl files in order and then infers the relations among the tables.
+// It returns the names of the tables which are dropped or renamed by the ddl as well.
+func (s *Tables) parseFiles(paths []string) ([]string, GenerateErrors) {
+	var errs GenerateErrors
+	seen := map[string]struct{}{}
+	for _, path := range paths {
+		errs = append(errs, s.parseFile(path, seen)...)
 	}
+	s.resolveRelations()
 
+	var removed []string
+	for name := range seen {
+		if s.find(name) < 0 {
+			removed = append(removed, name)
+		}
+	}
+	sort.Strings(removed)
+	return removed, errs
+}
+
+// parseFile applies every statement in the ddl file to the tables, and adds the names of the tables to seen.
+// Errors are named after the table, or the file when the table name is unknown.
+func (s *Tables) parseFile(path string, seen map[string]struct{}) GenerateErrors {
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	var errs GenerateErrors
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		errs.add(name, LayerYAML, errors.Trace(err))
+		return errs
+	}
+	errs = s.replay(string(b), seen)
+	for _, e := range errs {
+		if e.Table == "" {
+			e.Table = name
+		}
+		d, ok := e.Err.(*Diagnostic)
+		if !ok {
+			e.Err = errors.Annotatef(e.Err, "cannot parse %s", path)
+			continue
+		}
+		d.Path = path
+		if d.Table == "" {
+			d.Table = name
+		}
+	}
 	for _, t := range *s {
-		ymlPath := filepath.Join(yamlDir, fmt.Sprintf("%s.yml", t.Name))
-		f, err := os.Create(ymlPath)
+		if t.path == "" {
+			t.path = path
+		}
+	}
+	return errs
+}
+
+var (
+	createTableRegexp  = regexp.MustCompile(`(?i)^create\s+(temporary\s+)?table\b`)
+	ifNotExistsRegexp  = regexp.MustCompile(`(?i)^create\s+(temporary\s+)?table\s+if\s+not\s+exists\b`)
+	tableNameRegexp    = regexp.MustCompile("(?is)^create\\s+(?:temporary\\s+)?table\\s+(?:if\\s+not\\s+exists\\s+)?([`\\w.]+)")
+	tableCommentRegexp = regexp.MustCompile(`(?is)\bcomment\s*=?\s*'(.*?)'(?:\s+[a-z_ ]+=|\s*$)`)
+)
+
+// parseDDL splits s into statements and replays them in order.
+// CREATE TABLE adds a table, ALTER TABLE, RENAME TABLE and DROP TABLE modify the tables defined so far,
+// and the other statements in schema dumps (SET, INSERT, ...) are skipped.
+// Errors are diagnostics located in s, which carry the 1-origin index of the statement as well.
+func (s *Tables) parseDDL(src string) GenerateErrors {
+	return s.replay(src, map[string]struct{}{})
+}
+
+// replay is parseDDL which adds the names of the tables after every statement to seen,
+// so the tables dropped or renamed by the ddl are known.
+func (s *Tables) replay(src string, seen map[string]struct{}) GenerateErrors {
+	var errs GenerateErrors
+	stmts, err := sqlparser.SplitStatementToPieces(src)
+	if err != nil {
+		errs.add("", LayerYAML, errors.Trace(err))
+		return errs
+	}
+	start := 0
+	for idx, stmt := range stmts {
+		name, err := s.apply(stmt)
 		if err != nil {
-			return errors.Trace(err)
+			for _, d := range diagnostics(name, err) {
+				d.Err = errors.Annotatef(d.Err, "statement #%d", idx+1)
+				d.locate(src, stmt, start)
+				errs.add(d.Table, LayerYAML, d)
+			}
+		} else if idx := s.find(name); idx >= 0 && (*s)[idx].src == "" {
+			// the table is created by the statement, lint diagnostics are located in it
+			t := (*s)[idx]
+			t.src, t.start, t.end = src, start, start+len(stmt)
 		}
-		enc := yaml.NewEncoder(f)
-		if err := enc.Encode(t); err != nil {
-			return errors.Trace(err)
+		for _, t := range *s {
+			seen[t.Name] = struct{}{}
 		}
-		if err := enc.Close(); err != nil {
-			return errors.Trace(err)
+		// statements are split at semicolons
+		start += len(stmt) + 1
+	}
+	return errs
+}
+
+// apply applies a statement and returns the name of the table it targets.
+func (s *Tables) apply(stmt string) (string, error) {
+	sql := strings.TrimSpace(sqlparser.StripLeadingComments(stmt))
+	switch {
+	case createTableRegexp.MatchString(sql):
+		t := &Table{}
+		if err := t.parse(stmt); err != nil {
+			return t.Name, errors.Trace(err)
+		}
+		if s.find(t.Name) >= 0 {
+			if ifNotExistsRegexp.MatchString(sql) {
+				return t.Name, nil
+			}
+			return t.Name, errors.Errorf("table %s already exists", t.Name)
 		}
-		log.Printf("output: %s", ymlPath)
+		*s = append(*s, t)
+		return t.Name, nil
+	case alterTableRegexp.MatchString(sql):
+		return s.alter(sql)
+	case renameTableRegexp.MatchString(sql):
+		return s.rename(sql)
+	case dropTableRegexp.MatchString(sql):
+		return s.drop(sql)
 	}
+	return "", nil
+}
 
-	return nil
+func (s Tables) find(name string) int {
+	for idx, t := range s {
+		if t.Name == name {
+			return idx
+		}
+	}
+	return -1
+}
+
+func (t *Table) render(yamlDir string) (*generatedFile, error) {
+	ymlPath := filepath.Join(yamlDir, fmt.Sprintf("%s.yml", t.Name))
+	out := &bytes.Buffer{}
+	enc := yaml.NewEncoder(out)
+	if err := enc.Encode(t); err != nil {
+		return nil, errors.Trace(err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return &generatedFile{table: t.Name, layer: LayerYAML, path: ymlPath, body: out.Bytes()}, nil
 }
 
-func (s *Tables) Load(rootPath string) error {
-	matches, err := filepath.Glob(filepath.Join(rootPath, "schema", "yaml", "*.yml"))
+func (s *Tables) Load(cfg *Config) error {
+	matches, err := filepath.Glob(filepath.Join(yamlDir(cfg), "*.yml"))
 	if err != nil {
 		return errors.Trace(err)
 	}
 
 	for _, path := range matches {
-		f, err := os.Open(path)
+		t, err := loadTable(path)
 		if err != nil {
 			return errors.Trace(err)
 		}
-		dec := yaml.NewDecoder(f)
-		var t *Table
-		if err := dec.Decode(&t); err != nil {
-			return errors.Trace(err)
-		}
-		if err := f.Close(); err != nil {
-			return errors.Trace(err)
-		}
 		*s = append(*s, t)
 	}
+	s.resolveConfig(cfg)
+	// the relations without loaders are reported when yaml is written
+	s.resolveLoaders()
 
 	return nil
 }
 
+// resolveConfig applies the settings of the project which are not written in yaml to every column.
+func (s Tables) resolveConfig(cfg *Config) {
+	for _, t := range s {
+		for _, c := range t.Columns {
+			c.Nullable = cfg.Nullable
+			c.Decimal = cfg.Decimal
+		}
+	}
+}
+
+func loadTable(path string) (*Table, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	defer f.Close()
+	dec := yaml.NewDecoder(f)
+	var t *Table
+	if err := dec.Decode(&t); err != nil {
+		return nil, errors.Annotatef(err, "cannot decode %s", path)
+	}
+	for _, c := range t.Columns {
+		if err := c.validateGoType(); err != nil {
+			return nil, errors.Annotatef(err, "invalid %s", path)
+		}
+	}
+	return t, nil
+}
+
 func (s *Tables) Entities() *Entities {
 	es := Entities{}
 	for _, t := range *s {
@@ -160,9 +358,16 @@ func (s *Tables) Models() *Models {
 }
 
 func (t *Table) parse(s string) error {
-	stmt, err := sqlparser.Parse(s)
+	// normalized has the same length as s, so the positions of syntax errors are kept
+	normalized, generated := normalizeDump(s)
+	parsed, relations := stripForeignKeys(normalized)
+	stmt, err := sqlparser.ParseStrictDDL(parsed)
 	if err != nil {
-		return errors.Trace(err)
+		// the name is still told when the rest cannot be parsed
+		if m := tableNameRegexp.FindStringSubmatch(strings.TrimSpace(sqlparser.StripLeadingComments(s))); m != nil {
+			t.Name = unquoteIdent(m[1])
+		}
+		return Diagnostics{syntaxError(t.Name, normalized, parsed, err)}
 	}
 	ddl, ok := stmt.(*sqlparser.DDL)
 	if !ok {
@@ -175,86 +380,175 @@ func (t *Table) parse(s string) error {
 		return errors.New("cannot find table spec")
 	}
 
-	p := pluralize.NewClient()
 	t.Name = ddl.NewName.Name.String()
-	if p.IsSingular(t.Name) {
-		return errors.New("not plural table name")
+	t.Comment = tableComment(ddl.TableSpec.Options)
+	t.Charset = tableOption(ddl.TableSpec.Options, tableCharsetRegexp)
+	t.Collation = tableOption(ddl.TableSpec.Options, tableCollationRegexp)
+	for _, i := range ddl.TableSpec.Indexes {
+		t.Indexes = append(t.Indexes, newIndex(i))
+	}
+	for _, c := range ddl.TableSpec.Columns {
+		column, err := newColumn(c)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		_, column.IsGenerated = generated[column.Name]
+		t.Columns = append(t.Columns, column)
 	}
+	for _, r := range relations {
+		if err := t.addRelation(r); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	t.resolve()
 
-	var primaryIndex *Index
-	indexesColumnMap := map[string][]*Index{}
-	for _, i := range ddl.TableSpec.Indexes {
-		info := i.Info
-		index := &Index{
-			Name:         info.Name.String(),
-			IsPrimaryKey: info.Primary,
-			IsUnique:     info.Unique,
-			Columns:      []string{},
-		}
-		for _, c := range i.Columns {
-			columnName := c.Column.String()
-			if p.IsPlural(columnName) {
-				return errors.New("not singular column name")
-			}
-			index.Columns = append(index.Columns, columnName)
-			indexesColumnMap[columnName] = append(indexesColumnMap[columnName], index)
+	if ds := t.validate(); len(ds) > 0 {
+		return ds
+	}
+	return nil
+}
+
+func newIndex(i *sqlparser.IndexDefinition) *Index {
+	info := i.Info
+	index := &Index{
+		Name:         info.Name.String(),
+		IsPrimaryKey: info.Primary,
+		IsUnique:     info.Unique,
+		Columns:      []string{},
+	}
+	for _, c := range i.Columns {
+		index.Columns = append(index.Columns, c.Column.String())
+	}
+	return index
+}
+
+func newColumn(c *sqlparser.ColumnDefinition) (*Column, error) {
+	ct := c.Type
+	column := &Column{
+		Name:            c.Name.String(),
+		ColumnType:      ColumnType(ct.Type),
+		IsAutoIncrement: bool(ct.Autoincrement),
+		IsUnsigned:      bool(ct.Unsigned),
+		IsNotNull:       bool(ct.NotNull),
+		UniqueIndexKeys: []string{},
+		IndexKeys:       []string{},
+	}
+	if ct.Length != nil {
+		size, err := strconv.ParseUint(string(ct.Length.Val), 10, 64)
+		if err != nil {
+			return nil, err
 		}
-		t.Indexes = append(t.Indexes, index)
-		if info.Primary {
-			primaryIndex = index
+		column.Size = size
+	}
+	if ct.Scale != nil {
+		scale, err := strconv.ParseUint(string(ct.Scale.Val), 10, 64)
+		if err != nil {
+			return nil, err
 		}
+		column.Scale = scale
+	}
+	column.EntityType = column.entityType()
+	if ct.Comment != nil {
+		column.Comment = string(ct.Comment.Val)
 	}
-	if primaryIndex == nil {
-		return errors.New("need primary key")
+	if column.ColumnType == Enum || column.ColumnType == Set {
+		for _, v := range ct.EnumValues {
+			column.EnumValues = append(column.EnumValues, unquoteEnumValue(v))
+		}
 	}
-	if len(primaryIndex.Columns) > 1 {
-		return errors.New("not single primary key")
+	if ct.Default != nil {
+		defaultStr := string(ct.Default.Val)
+		if defaultStr != "null" {
+			column.DefaultValue = defaultStr
+		}
 	}
+	if ct.OnUpdate != nil {
+		column.OnUpdate = string(ct.OnUpdate.Val)
+	}
+	column.Charset = ct.Charset
+	column.Collation = ct.Collate
+	return column, nil
+}
 
-	for _, c := range ddl.TableSpec.Columns {
-		ct := c.Type
-		column := &Column{
-			Name:            c.Name.String(),
-			ColumnType:      ColumnType(ct.Type),
-			IsAutoIncrement: bool(ct.Autoincrement),
-			IsUnsigned:      bool(ct.Unsigned),
-			IsNotNull:       bool(ct.NotNull),
-			UniqueIndexKeys: []string{},
-			IndexKeys:       []string{},
-		}
-		if ct.Length != nil {
-			size, err := strconv.ParseUint(string(ct.Length.Val), 10, 64)
-			if err != nil {
-				return err
-			}
-			column.Size = size
+// tableComment returns COMMENT of the table options.
+// sqlparser keeps the options as a string with the quotes in the comment unescaped,
+// so the comment ends at the quote followed by the next option or the end.
+func tableComment(options string) string {
+	m := tableCommentRegexp.FindStringSubmatch(options)
+	if m == nil {
+		return ""
+	}
+	return m[1]
+}
+
+// unquoteEnumValue removes the quotes which sqlparser leaves around the values of ENUM and SET.
+func unquoteEnumValue(v string) string {
+	if len(v) >= 2 && (v[0] == '\'' || v[0] == '"') && v[len(v)-1] == v[0] {
+		return v[1 : len(v)-1]
+	}
+	return v
+}
+
+// resolve updates the fields derived from the name, columns and indexes.
+func (t *Table) resolve() {
+	indexesColumnMap := map[string][]*Index{}
+	for _, index := range t.Indexes {
+		for _, columnName := range index.Columns {
+			indexesColumnMap[columnName] = append(indexesColumnMap[columnName], index)
 		}
-		column.EntityType = column.entityType()
-		if ct.Default != nil {
-			defaultStr := string(ct.Default.Val)
-			if defaultStr != "null" {
-				column.DefaultValue = defaultStr
+	}
+	for _, column := range t.Columns {
+		column.IsPrimaryKey = false
+		column.UniqueIndexKeys = []string{}
+		column.IndexKeys = []string{}
+		for _, i := range indexesColumnMap[column.Name] {
+			if i.IsPrimaryKey {
+				column.IsPrimaryKey = true
+			} else if i.IsUnique {
+				column.UniqueIndexKeys = append(column.UniqueIndexKeys, i.Name)
+			} else {
+				column.IndexKeys = append(column.IndexKeys, i.Name)
 			}
 		}
-		if indexes, exists := indexesColumnMap[column.Name]; exists {
-			for _, i := range indexes {
-				if i.IsPrimaryKey {
-					column.IsPrimaryKey = true
-				} else if i.IsUnique {
-					column.UniqueIndexKeys = append(column.UniqueIndexKeys, i.Name)
-				} else {
-					column.IndexKeys = append(column.IndexKeys, i.Name)
+	}
+
+	t.IsReadOnly = isReadOnlyTable(t.Name)
+}
+
+// isReadOnlyTable reports whether the table is master data, which is not written by the application.
+func isReadOnlyTable(name string) bool {
+	return !strings.HasPrefix(name, "user_") && name != "users"
+}
+
+// primaryKeyColumns returns the columns of the primary key in the order of the index.
+func (t *Table) primaryKeyColumns() []*Column {
+	var columns []*Column
+	for _, index := range t.Indexes {
+		if !index.IsPrimaryKey {
+			continue
+		}
+		for _, name := range index.Columns {
+			for _, c := range t.Columns {
+				if c.Name == name {
+					columns = append(columns, c)
 				}
 			}
 		}
-		t.Columns = append(t.Columns, column)
 	}
+	return columns
+}
 
-	if !strings.HasPrefix(t.Name, "user_") && t.Name != "users" {
-		t.IsReadOnly = true
+// validate returns every problem which stops the generation of the table.
+// Naming rules are checked by lint after the whole ddl is applied.
+func (t *Table) validate() Diagnostics {
+	var ds Diagnostics
+	for _, index := range t.Indexes {
+		if index.IsPrimaryKey {
+			return ds
+		}
 	}
-
-	return nil
+	ds.add(SeverityError, t.Name, "", errors.New("need primary key"))
+	return ds
 }
 
 func (c *Column) entityType() EntityType {
@@ -284,8 +578,11 @@ func (c *Column) entityType() EntityType {
 		return Int8
 	case Float:
 		return Float32
-	case Double, Decimal, Numeric:
+	case Double:
 		return Float64
+	case Decimal, Numeric:
+		// float64 loses the exact value, see decimalType for the type of the field
+		return String
 	case Bit:
 		if c.Size > 32 {
 			return Uint64
@@ -301,6 +598,8 @@ func (c *Column) entityType() EntityType {
 		return ByteSlice
 	case Set:
 		return StringSlice
+	case JSON:
+		return RawJSON
 	}
 	return String
 }
@@ -78,4 +78,253 @@ CREATE TABLE IF NOT EXISTS items (
 		assert.Equals(t, col.Name, "open_at")
 		assert.Equals(t, col.EntityType, TimePtr)
 	})
+
+	t.Run("parse_ddl_multiple", func(t *testing.T) {
+		ddl := `
+-- MySQL dump
+/*!40101 SET @OLD_CHARACTER_SET_CLIENT=@@CHARACTER_SET_CLIENT */;
+DROP TABLE IF EXISTS items;
+INSERT INTO items VALUES (1);
+/*!40101 SET character_set_client = utf8 */;
+CREATE TABLE items (
+  id BIGINT(20) UNSIGNED NOT NULL COMMENT 'a;b',
+  PRIMARY KEY (id)
+) ENGINE=InnoDB;
+SET NAMES utf8mb4;
+CREATE TABLE user_item (
+  id BIGINT(20) UNSIGNED NOT NULL
+);
+CREATE TABLE user_items (
+  id BIGINT(20) UNSIGNED NOT NULL,
+  PRIMARY KEY (id)
+);
+`
+		tables := Tables{}
+		errs := tables.parseDDL(ddl)
+		assert.Len(t, tables, 2)
+		assert.Equals(t, tables[0].Name, "items")
+		assert.Equals(t, tables[1].Name, "user_items")
+
+		assert.Len(t, errs, 1)
+		assert.Equals(t, errs[0].Table, "user_item")
+		assert.True(t, strings.Contains(errs[0].Error(), "statement #7"))
+	})
+
+	t.Run("parse_ddl_alter", func(t *testing.T) {
+		ddl := `
+CREATE TABLE user_logs (
+  id BIGINT(20) UNSIGNED NOT NULL AUTO_INCREMENT,
+  user_id BIGINT(20) UNSIGNED NOT NULL,
+  memo VARCHAR(40) NOT NULL,
+  PRIMARY KEY (id),
+  KEY idx_user_id (user_id)
+);
+ALTER TABLE user_logs
+  ADD COLUMN is_read TINYINT(1) NOT NULL DEFAULT '0' AFTER user_id,
+  ADD COLUMN created_at DATETIME,
+  ADD UNIQUE KEY uq_user_memo (user_id, memo),
+  DROP INDEX idx_user_id,
+  ENGINE=InnoDB;
+ALTER TABLE user_logs CHANGE memo message VARCHAR(80) NOT NULL;
+ALTER TABLE user_logs DROP COLUMN created_at, ADD INDEX (is_read);
+ALTER TABLE user_logs RENAME TO user_histories;
+ALTER TABLE user_histories DROP COLUMN id;
+CREATE TABLE user_friends (id BIGINT(20) UNSIGNED NOT NULL, PRIMARY KEY (id));
+RENAME TABLE user_friends TO user_followers;
+DROP TABLE IF EXISTS user_followers, user_others;
+ALTER TABLE user_others ADD COLUMN name VARCHAR(40);
+`
+		tables := Tables{}
+		errs := tables.parseDDL(ddl)
+		assert.Len(t, tables, 1)
+		table := tables[0]
+		assert.Equals(t, table.Name, "user_histories")
+
+		var names []string
+		for _, c := range table.Columns {
+			names = append(names, c.Name)
+		}
+		assert.Equals(t, names, []string{"id", "user_id", "is_read", "message"})
+		col := table.Columns[2]
+		assert.Equals(t, col.EntityType, Bool)
+		assert.Equals(t, col.DefaultValue, "0")
+		assert.Equals(t, col.IndexKeys, []string{"is_read"})
+		col = table.Columns[3]
+		assert.Equals(t, col.Size, uint64(80))
+		assert.Equals(t, col.UniqueIndexKeys, []string{"uq_user_memo"})
+		assert.Equals(t, table.Indexes[1].Columns, []string{"user_id", "message"})
+
+		// dropping the primary key column is rejected and the table is kept as is
+		assert.Len(t, errs, 2)
+		assert.Equals(t, errs[0].Table, "user_histories")
+		assert.True(t, strings.Contains(errs[0].Error(), "statement #6"))
+		assert.Equals(t, errs[1].Table, "user_others")
+		assert.True(t, strings.Contains(errs[1].Error(), "statement #10"))
+	})
+	t.Run("parse_comment", func(t *testing.T) {
+		ddl := `
+CREATE TABLE user_cards (
+  id BIGINT(20) UNSIGNED NOT NULL COMMENT 'card id',
+  name VARCHAR(40) NOT NULL COMMENT 'the ''name''',
+  PRIMARY KEY (id)
+) ENGINE=InnoDB COMMENT='cards of the user' DEFAULT CHARSET=utf8mb4;
+CREATE TABLE user_items (
+  id BIGINT(20) UNSIGNED NOT NULL,
+  PRIMARY KEY (id)
+) COMMENT 'it''s the user''s items';
+ALTER TABLE user_cards COMMENT = 'owned cards', MODIFY name VARCHAR(40) NOT NULL COMMENT 'nickname';
+`
+		tables := Tables{}
+		errs := tables.parseDDL(ddl)
+		assert.Len(t, errs, 0)
+		assert.Equals(t, tables[0].Comment, "owned cards")
+		assert.Equals(t, tables[0].Columns[0].Comment, "card id")
+		assert.Equals(t, tables[0].Columns[1].Comment, "nickname")
+		assert.Equals(t, tables[1].Comment, "it's the user's items")
+	})
+	t.Run("parse_relation", func(t *testing.T) {
+		ddl := `
+CREATE TABLE users (
+  id BIGINT(20) UNSIGNED NOT NULL,
+  outside_user_id VARCHAR(40) NOT NULL,
+  PRIMARY KEY (id)
+);
+CREATE TABLE items (
+  id INT(10) UNSIGNED NOT NULL,
+  PRIMARY KEY (id)
+);
+CREATE TABLE user_items (
+  id BIGINT(20) UNSIGNED NOT NULL,
+  user_id BIGINT(20) UNSIGNED NOT NULL,
+  item_id INT(10) UNSIGNED NOT NULL,
+  owner BIGINT(20) UNSIGNED NOT NULL,
+  PRIMARY KEY (id),
+  CONSTRAINT fk_owner FOREIGN KEY (owner) REFERENCES users (id) ON DELETE CASCADE
+);
+ALTER TABLE user_items ADD CONSTRAINT fk_item FOREIGN KEY (item_id) REFERENCES items (id), DROP FOREIGN KEY fk_owner;
+ALTER TABLE user_items ADD COLUMN giver_user_id BIGINT(20) UNSIGNED NOT NULL;
+RENAME TABLE items TO goods;
+`
+		tables := Tables{}
+		errs := tables.parseDDL(ddl)
+		assert.Len(t, errs, 0)
+		tables.resolveRelations()
+
+		// string outside_user_id does not refer to users.id
+		assert.Len(t, tables[0].Relations, 0)
+		relations := tables[2].Relations
+		assert.Len(t, relations, 3)
+		assert.Equals(t, *relations[0], Relation{Name: "fk_item", Columns: []string{"item_id"}, RefTable: "goods", RefColumns: []string{"id"}})
+		assert.Equals(t, *relations[1], Relation{Columns: []string{"user_id"}, RefTable: "users", RefColumns: []string{"id"}, IsInferred: true})
+		assert.Equals(t, *relations[2], Relation{Columns: []string{"giver_user_id"}, RefTable: "users", RefColumns: []string{"id"}, IsInferred: true})
+
+		errs = tables.parseDDL("ALTER TABLE user_items DROP FOREIGN KEY fk_unknown;")
+		assert.Len(t, errs, 1)
+	})
+	t.Run("parse_dump", func(t *testing.T) {
+		ddl := "-- MySQL dump 10.13\n" +
+			"/*!40101 SET @OLD_CHARACTER_SET_CLIENT=@@CHARACTER_SET_CLIENT */;\n" +
+			"DROP TABLE IF EXISTS `user_posts`;\n" +
+			"/*!40101 SET @saved_cs_client     = @@character_set_client */;\n" +
+			"CREATE TABLE `user_posts` (\n" +
+			"  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT,\n" +
+			"  `user_id` bigint(20) unsigned NOT NULL,\n" +
+			"  `uuid` char(36) CHARACTER SET ascii COLLATE ascii_bin NOT NULL DEFAULT (uuid()),\n" +
+			"  `title` varchar(255) COLLATE utf8mb4_unicode_ci NOT NULL,\n" +
+			"  `body` text NOT NULL /*!80023 INVISIBLE */,\n" +
+			"  `location` point NOT NULL /*!80003 SRID 4326 */,\n" +
+			"  `created_at` datetime(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),\n" +
+			"  `updated_at` datetime(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6) ON UPDATE CURRENT_TIMESTAMP(6),\n" +
+			"  PRIMARY KEY (`id`) USING BTREE,\n" +
+			"  UNIQUE KEY `uq_uuid` (`uuid`) KEY_BLOCK_SIZE=8,\n" +
+			"  KEY `idx_user_id` USING BTREE (`user_id`,`created_at` DESC),\n" +
+			"  KEY `idx_title` (`title`(10)),\n" +
+			"  FULLTEXT KEY `ft_body` (`body`) /*!50100 WITH PARSER `ngram` */ ,\n" +
+			"  SPATIAL KEY `sp_location` (`location`),\n" +
+			"  CONSTRAINT `fk_user` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE,\n" +
+			"  CONSTRAINT `chk_title` CHECK ((`title` <> _utf8mb4'')) /*!80016 NOT ENFORCED */\n" +
+			") ENGINE=InnoDB AUTO_INCREMENT=123 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin ROW_FORMAT=DYNAMIC COMMENT='posts, charset=latin1'\n" +
+			"/*!50100 PARTITION BY HASH (`id`) PARTITIONS 4 */;\n" +
+			"/*!40101 SET character_set_client = @saved_cs_client */;\n" +
+			"ALTER TABLE `user_posts` ADD COLUMN `memo` varchar(40) CHARACTER SET latin1 DEFAULT NULL, DEFAULT CHARSET=utf8mb3;\n"
+		tables := Tables{}
+		errs := tables.parseDDL(ddl)
+		assert.Len(t, errs, 0)
+		assert.Len(t, tables, 1)
+		table := tables[0]
+		assert.Equals(t, table.Comment, "posts, charset=latin1")
+		assert.Equals(t, table.Charset, "utf8mb3")
+		assert.Equals(t, table.Collation, "utf8mb4_bin")
+
+		var names []string
+		for _, c := range table.Columns {
+			names = append(names, c.Name)
+		}
+		assert.Equals(t, names, []string{"id", "user_id", "uuid", "title", "body", "location", "created_at", "updated_at", "memo"})
+		uuid := table.Columns[2]
+		assert.Equals(t, []string{uuid.Charset, uuid.Collation, uuid.DefaultValue}, []string{"ascii", "ascii_bin", ""})
+		assert.Equals(t, table.Columns[3].Collation, "utf8mb4_unicode_ci")
+		assert.Equals(t, table.Columns[6].OnUpdate, "")
+		assert.Equals(t, table.Columns[7].OnUpdate, "current_timestamp")
+		assert.Equals(t, table.Columns[8].Charset, "latin1")
+
+		var indexes []string
+		for _, index := range table.Indexes {
+			indexes = append(indexes, index.Name+strings.Join(index.Columns, ","))
+		}
+		assert.Equals(t, indexes, []string{"PRIMARYid", "uq_uuiduuid", "idx_user_iduser_id,created_at", "idx_titletitle"})
+		assert.Equals(t, table.Relations[0].Name, "fk_user")
+
+		errs = tables.parseDDL("ALTER TABLE user_posts CONVERT TO CHARACTER SET utf8mb4 COLLATE utf8mb4_ja_0900_as_cs;")
+		assert.Len(t, errs, 0)
+		assert.Equals(t, []string{table.Charset, table.Collation}, []string{"utf8mb3", "utf8mb4_bin"})
+		table = tables[0]
+		assert.Equals(t, []string{table.Charset, table.Collation}, []string{"utf8mb4", "utf8mb4_ja_0900_as_cs"})
+		assert.Equals(t, table.Columns[8].Charset, "")
+		// ignored definitions may come before the columns
+		errs = tables.parseDDL("CREATE TABLE `user_notes` (\n" +
+			"  CHECK (`id` > 0),\n" +
+			"  FULLTEXT KEY `ft_body` (`body`),\n" +
+			"  `id` bigint(20) unsigned NOT NULL,\n" +
+			"  `body` text NOT NULL,\n" +
+			"  PRIMARY KEY (`id`)\n" +
+			");")
+		assert.Len(t, errs, 0)
+		assert.Len(t, tables, 2)
+		assert.Equals(t, len(tables[1].Columns), 2)
+		normalized, _ := normalizeDump("CREATE TABLE t (CHECK (a > 0), a INT)")
+		assert.Equals(t, normalized, "CREATE TABLE t (               a INT)")
+	})
+	t.Run("parse_ddl_diagnostics", func(t *testing.T) {
+		ddl := `-- every problem is reported
+CREATE TABLE items (
+  id BIGINT(20) UNSIGNED NOT NULL,
+  tags VARCHAR(40) NOT NULL,
+  KEY idx_tags (tags)
+);
+CREATE TABLE user_items (
+  id BIGINT(20) UNSIGNED NOT NULL,
+  amount INT NOT NULL DEFAULT,
+  PRIMARY KEY (id)
+);
+ALTER TABLE users ADD COLUMN name VARCHAR(40);
+`
+		tables := Tables{}
+		errs := tables.parseDDL(ddl)
+		assert.Len(t, tables, 0)
+		assert.Len(t, errs, 3)
+
+		var ds []*Diagnostic
+		for _, e := range errs {
+			d, ok := e.Err.(*Diagnostic)
+			assert.True(t, ok)
+			assert.Equals(t, d.Severity, SeverityError)
+			ds = append(ds, d)
+		}
+		assert.Equals(t, []interface{}{ds[0].Table, ds[0].Line, ds[0].Col}, []interface{}{"items", 2, 14})
+		assert.True(t, strings.Contains(ds[0].Error(), "need primary key"))
+		assert.Equals(t, []interface{}{ds[1].Table, ds[1].Line, ds[1].Col}, []interface{}{"user_items", 9, 30})
+		assert.Equals(t, errs[1].Error(), "[yaml] 9:30: error: user_items: statement #2: syntax error at position 93")
+		assert.Equals(t, []interface{}{ds[2].Table, ds[2].Line, ds[2].Col}, []interface{}{"users", 12, 13})
+	})
 }
@@ -1,26 +1,35 @@
 package remodel
 
 import (
-	"io/ioutil"
-	"os"
+	"strings"
 
+	"github.com/iancoleman/strcase"
 	"github.com/juju/errors"
 	"golang.org/x/tools/imports"
 )
 
-func applyGoimports(codePath string) error {
+const generatedCodeHeader = "// Code generated by generate_code script - DO NOT EDIT.\n"
+
+func applyGoimports(codePath string, src []byte) ([]byte, error) {
 	importOpts := &imports.Options{
 		TabWidth:  4,
 		TabIndent: true,
 		Comments:  true,
 		Fragment:  true,
 	}
-	b, err := imports.Process(codePath, nil, importOpts)
+	b, err := imports.Process(codePath, src, importOpts)
 	if err != nil {
-		return errors.Trace(err)
+		return nil, errors.Trace(err)
 	}
-	if err := ioutil.WriteFile(codePath, b, os.ModePerm); err != nil {
-		return errors.Trace(err)
+	return b, nil
+}
+
+// toFieldName converts a column name to the field name of entity, e.g. user_id to UserID.
+func toFieldName(columnName string) string {
+	fieldName := strcase.ToCamel(columnName)
+	if strings.HasSuffix(fieldName, "Id") {
+		l := len(fieldName)
+		fieldName = fieldName[:l-2] + "ID"
 	}
-	return nil
+	return fieldName
 }
@@ -0,0 +1,305 @@
+package remodel
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/juju/errors"
+)
+
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+type snapshot map[string]fileStamp
+
+type watcher struct {
+	cfg     *Config
+	sqlDir  string
+	yamlDir string
+}
+
+// Watch polls (root)/schema/sql and (root)/schema/yaml every interval and regenerates
+// yaml, entity, proto, dao and model of the changed tables only. It returns when stop is closed.
+func Watch(cfg *Config, interval time.Duration, stop <-chan struct{}) error {
+	w := newWatcher(cfg)
+	prev, err := w.snapshot()
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	log.Printf("watching: %s, %s", w.sqlDir, w.yamlDir)
+	for {
+		select {
+		case <-stop:
+			return nil
+		case <-ticker.C:
+		}
+		prev = w.poll(prev)
+	}
+}
+
+func newWatcher(cfg *Config) *watcher {
+	return &watcher{
+		cfg:     cfg,
+		sqlDir:  filepath.Join(cfg.RootDir, "schema", "sql"),
+		yamlDir: yamlDir(cfg),
+	}
+}
+
+// poll regenerates the tables changed since prev and returns the snapshot to compare at the next tick.
+func (w *watcher) poll(prev snapshot) snapshot {
+	cur, err := w.snapshot()
+	if err != nil {
+		log.Printf("err: %+v", err)
+		return prev
+	}
+	changed, removed := prev.diff(cur)
+	if len(changed) == 0 && len(removed) == 0 {
+		return prev
+	}
+	if err := w.regenerate(changed, removed); err != nil {
+		log.Printf("err: %v", err)
+	}
+
+	// yaml files written by regenerate must not be detected as changes at the next tick
+	next, err := w.snapshot()
+	if err != nil {
+		log.Printf("err: %+v", err)
+		next = cur
+	}
+	if !prev.sameFiles(next, w.yamlDir) {
+		if err := w.regenerateStructable(); err != nil {
+			log.Printf("err: %v", err)
+		}
+	}
+	return next
+}
+
+func (w *watcher) snapshot() (snapshot, error) {
+	s := snapshot{}
+	for _, dir := range []string{w.sqlDir, w.yamlDir} {
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			continue
+		}
+		if err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return errors.Trace(err)
+			}
+			if info.IsDir() {
+				return nil
+			}
+			if ext := filepath.Ext(path); ext != ".sql" && ext != ".yml" {
+				return nil
+			}
+			s[path] = fileStamp{modTime: info.ModTime(), size: info.Size()}
+			return nil
+		}); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	return s, nil
+}
+
+func (s snapshot) diff(cur snapshot) (changed, removed []string) {
+	for path, stamp := range cur {
+		if old, exists := s[path]; !exists || old != stamp {
+			changed = append(changed, path)
+		}
+	}
+	for path := range s {
+		if _, exists := cur[path]; !exists {
+			removed = append(removed, path)
+		}
+	}
+	sort.Strings(changed)
+	sort.Strings(removed)
+	return changed, removed
+}
+
+func (s snapshot) sameFiles(other snapshot, dir string) bool {
+	prefix := dir + string(filepath.Separator)
+	count := 0
+	for path := range s {
+		if !strings.HasPrefix(path, prefix) {
+			continue
+		}
+		if _, exists := other[path]; !exists {
+			return false
+		}
+		count++
+	}
+	for path := range other {
+		if strings.HasPrefix(path, prefix) {
+			count--
+		}
+	}
+	return count == 0
+}
+
+func (w *watcher) regenerate(changed, removed []string) error {
+	var (
+		files []*generatedFile
+		errs  GenerateErrors
+	)
+	generated := map[string]struct{}{}
+
+	paths := append(append([]string{}, changed...), removed...)
+	// ddl goes first because it rewrites yaml of the same table
+	var sqlChanged bool
+	for _, path := range paths {
+		if filepath.Ext(path) == ".sql" {
+			log.Printf("changed: %s", path)
+			sqlChanged = true
+		}
+	}
+	if sqlChanged {
+		fs, es := w.regenerateDDL(generated)
+		files = append(files, fs...)
+		errs = append(errs, es...)
+	}
+
+	names := map[string]struct{}{}
+	for _, path := range paths {
+		if filepath.Ext(path) != ".yml" {
+			continue
+		}
+		name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		if _, exists := generated[name]; exists {
+			continue
+		}
+		log.Printf("changed: %s", path)
+		names[name] = struct{}{}
+	}
+	if len(names) > 0 {
+		// loaders of model refer to the other tables, so the whole yaml is loaded once
+		ts := &Tables{}
+		if err := ts.Load(w.cfg); err != nil {
+			errs.add("", LayerYAML, err)
+		} else {
+			referrers := ts.referrers(names)
+			for _, t := range *ts {
+				_, isChanged := names[t.Name]
+				_, refers := referrers[t.Name]
+				_, done := generated[t.Name]
+				if done || !(isChanged || refers) || !w.cfg.matchTable(t.Name) {
+					continue
+				}
+				fs, es := t.generate(w.cfg)
+				files = append(files, fs...)
+				errs = append(errs, es...)
+			}
+		}
+	}
+
+	errs = append(errs, writeGenerated(w.cfg, files)...)
+	return errs.orNil()
+}
+
+// regenerateDDL replays every ddl file because a migration may alter tables created in other files,
+// and regenerates the tables whose yaml differs from the one on disk and the tables referring to them.
+func (w *watcher) regenerateDDL(generated map[string]struct{}) ([]*generatedFile, GenerateErrors) {
+	var (
+		files []*generatedFile
+		errs  GenerateErrors
+	)
+	paths, err := sqlFiles(w.cfg)
+	if err != nil {
+		errs.add("", LayerYAML, err)
+		return nil, errs
+	}
+	ts := &Tables{}
+	removed, parseErrs := ts.parseAndLint(w.cfg, paths)
+	errs = append(errs, parseErrs...)
+	ts.resolveConfig(w.cfg)
+	errs = append(errs, ts.keepGoTypes(w.yamlDir)...)
+	// files of a table whose ddl is broken must not be taken as stale
+	if len(errs) == 0 && len(removed) > 0 {
+		errs = append(errs, w.removeStale(ts, removed)...)
+	}
+
+	changed := map[string]struct{}{}
+	for _, name := range removed {
+		changed[name] = struct{}{}
+	}
+	yamls := map[string]*generatedFile{}
+	for _, t := range *ts {
+		f, err := t.render(w.yamlDir)
+		if err != nil {
+			errs.add(t.Name, LayerYAML, err)
+			continue
+		}
+		current, err := readFileIfExists(f.path)
+		if err != nil {
+			errs.add(t.Name, LayerYAML, err)
+			continue
+		}
+		if !bytes.Equal(current, f.body) {
+			yamls[t.Name] = f
+			changed[t.Name] = struct{}{}
+		}
+	}
+	// a table whose relation refers to a changed table may gain or lose its loader
+	referrers := ts.referrers(changed)
+	for _, t := range *ts {
+		f, isChanged := yamls[t.Name]
+		_, refers := referrers[t.Name]
+		if !(isChanged || refers) || !w.cfg.matchTable(t.Name) {
+			continue
+		}
+		if isChanged {
+			files = append(files, f)
+		}
+		fs, es := t.generate(w.cfg)
+		files = append(files, fs...)
+		errs = append(errs, es...)
+		generated[t.Name] = struct{}{}
+	}
+	return files, errs
+}
+
+// removeStale removes the files of the tables dropped or renamed by the ddl with -prune as generate does.
+// Without it, only yaml is removed and the other stale files are logged, they no longer compile after a rename.
+func (w *watcher) removeStale(ts *Tables, removed []string) GenerateErrors {
+	var errs GenerateErrors
+	if w.cfg.Prune {
+		errs.add("", LayerPrune, ts.Prune(w.cfg))
+		return errs
+	}
+	errs = append(errs, removeYAML(w.cfg, removed)...)
+	expected, err := ts.generatedPaths(w.cfg)
+	if err != nil {
+		errs.add("", LayerPrune, err)
+		return errs
+	}
+	stale, err := orphanedFiles(w.cfg, expected)
+	if err != nil {
+		errs.add("", LayerPrune, err)
+		return errs
+	}
+	for _, path := range stale {
+		log.Printf("warning: %s is stale, run with -prune to remove it", relativePath(w.cfg.RootDir, path))
+	}
+	return errs
+}
+
+// regenerateStructable rewrites structable.go from the whole table set.
+func (w *watcher) regenerateStructable() error {
+	ts := &Tables{}
+	if err := ts.Load(w.cfg); err != nil {
+		return errors.Trace(err)
+	}
+	f, err := ts.Entities().renderStructable(w.cfg)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return writeGenerated(w.cfg, []*generatedFile{f}).orNil()
+}
@@ -0,0 +1,303 @@
+package remodel
+
+import (
+	"bytes"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yuki-eto/remodel/assert"
+)
+
+// recordWriter writes files to disk and records the paths relative to root.
+type recordWriter struct {
+	DiskWriter
+	root    string
+	written []string
+	removed []string
+}
+
+func (w *recordWriter) WriteFile(path string, b []byte) error {
+	w.written = append(w.written, relativePath(w.root, path))
+	return w.DiskWriter.WriteFile(path, b)
+}
+
+func (w *recordWriter) Remove(path string) error {
+	w.removed = append(w.removed, relativePath(w.root, path))
+	return w.DiskWriter.Remove(path)
+}
+
+func writeTestFile(t *testing.T, path, body string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, []byte(body), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSnapshot_diff(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		change  func(t *testing.T, dir string)
+		changed []string
+		removed []string
+	}{
+		{
+			name:   "none",
+			change: func(t *testing.T, dir string) {},
+		},
+		{
+			name: "size",
+			change: func(t *testing.T, dir string) {
+				writeTestFile(t, filepath.Join(dir, "schema", "sql", "users.sql"), "CREATE TABLE users (id INT);\n")
+			},
+			changed: []string{"schema/sql/users.sql"},
+		},
+		{
+			name: "mtime",
+			change: func(t *testing.T, dir string) {
+				later := time.Now().Add(time.Hour)
+				if err := os.Chtimes(filepath.Join(dir, "schema", "yaml", "users.yml"), later, later); err != nil {
+					t.Fatal(err)
+				}
+			},
+			changed: []string{"schema/yaml/users.yml"},
+		},
+		{
+			name: "added",
+			change: func(t *testing.T, dir string) {
+				writeTestFile(t, filepath.Join(dir, "schema", "sql", "items.sql"), "")
+			},
+			changed: []string{"schema/sql/items.sql"},
+		},
+		{
+			name: "removed",
+			change: func(t *testing.T, dir string) {
+				if err := os.Remove(filepath.Join(dir, "schema", "yaml", "users.yml")); err != nil {
+					t.Fatal(err)
+				}
+			},
+			removed: []string{"schema/yaml/users.yml"},
+		},
+		{
+			name: "other_extension",
+			change: func(t *testing.T, dir string) {
+				writeTestFile(t, filepath.Join(dir, "schema", "sql", "README.md"), "# schema\n")
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "remodel")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+			writeTestFile(t, filepath.Join(dir, "schema", "sql", "users.sql"), "CREATE TABLE users;\n")
+			writeTestFile(t, filepath.Join(dir, "schema", "yaml", "users.yml"), "name: users\n")
+
+			w := newWatcher(NewConfig(dir))
+			prev := mustSnapshot(t, w)
+			tc.change(t, dir)
+			cur := mustSnapshot(t, w)
+			changed, removed := prev.diff(cur)
+			rel := func(paths []string) []string {
+				var s []string
+				for _, path := range paths {
+					s = append(s, relativePath(dir, path))
+				}
+				return s
+			}
+			assert.Equals(t, rel(changed), tc.changed)
+			assert.Equals(t, rel(removed), tc.removed)
+			assert.Equals(t, prev.sameFiles(cur, w.yamlDir), tc.name != "removed")
+		})
+	}
+}
+
+func TestWatcher_poll(t *testing.T) {
+	ddl := map[string]string{
+		"users.sql": `
+CREATE TABLE users (
+  id BIGINT(20) UNSIGNED NOT NULL AUTO_INCREMENT,
+  name VARCHAR(40) NOT NULL,
+  created_at DATETIME NOT NULL,
+  updated_at DATETIME NOT NULL,
+  PRIMARY KEY (id)
+);`,
+		"user_friends.sql": `
+CREATE TABLE user_friends (
+  id BIGINT(20) UNSIGNED NOT NULL AUTO_INCREMENT,
+  user_id BIGINT(20) UNSIGNED NOT NULL,
+  friend_id BIGINT(20) UNSIGNED NOT NULL,
+  created_at DATETIME NOT NULL,
+  updated_at DATETIME NOT NULL,
+  PRIMARY KEY (id),
+  FOREIGN KEY (friend_id) REFERENCES users (id)
+);`,
+		"items.sql": `
+CREATE TABLE items (
+  id BIGINT(20) UNSIGNED NOT NULL,
+  name VARCHAR(40) NOT NULL,
+  PRIMARY KEY (id)
+);`,
+	}
+	sqlPath := func(dir, name string) string {
+		return filepath.Join(dir, "schema", "sql", name)
+	}
+	yamlPath := func(dir, name string) string {
+		return filepath.Join(dir, "schema", "yaml", name)
+	}
+	appendFile := func(t *testing.T, path, body string) {
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		writeTestFile(t, path, string(b)+body)
+	}
+
+	for _, tc := range []struct {
+		name    string
+		prune   bool
+		change  func(t *testing.T, dir string)
+		written []string
+		removed []string
+		// stale is the files logged as stale without -prune
+		stale []string
+	}{
+		{
+			name: "alter_ddl",
+			change: func(t *testing.T, dir string) {
+				appendFile(t, sqlPath(dir, "items.sql"), "\nALTER TABLE items ADD COLUMN price INT NOT NULL;")
+			},
+			written: []string{"dao/item.go", "entity/item.go", "model/item.go", "schema/yaml/items.yml"},
+		},
+		{
+			name: "same_ddl",
+			change: func(t *testing.T, dir string) {
+				appendFile(t, sqlPath(dir, "items.sql"), "\n-- the yaml does not change\n")
+			},
+		},
+		{
+			name: "edit_yaml",
+			change: func(t *testing.T, dir string) {
+				b, err := ioutil.ReadFile(yamlPath(dir, "items.yml"))
+				if err != nil {
+					t.Fatal(err)
+				}
+				writeTestFile(t, yamlPath(dir, "items.yml"), strings.Replace(string(b), "name: items\n", "name: items\ncomment: goods\n", 1))
+			},
+			written: []string{"dao/item.go", "entity/item.go", "model/item.go"},
+		},
+		{
+			name: "alter_referenced_ddl",
+			change: func(t *testing.T, dir string) {
+				appendFile(t, sqlPath(dir, "users.sql"), "\nALTER TABLE users ADD COLUMN nickname VARCHAR(40) NOT NULL;")
+			},
+			// the loader of user_friends.friend_id depends on users
+			written: []string{"dao/user.go", "dao/user_friend.go", "entity/user.go", "entity/user_friend.go", "model/user.go", "model/user_friend.go", "schema/yaml/users.yml"},
+		},
+		{
+			name: "edit_referenced_yaml",
+			change: func(t *testing.T, dir string) {
+				b, err := ioutil.ReadFile(yamlPath(dir, "users.yml"))
+				if err != nil {
+					t.Fatal(err)
+				}
+				writeTestFile(t, yamlPath(dir, "users.yml"), strings.Replace(string(b), "name: users\n", "name: users\ncomment: players\n", 1))
+			},
+			written: []string{"dao/user.go", "dao/user_friend.go", "entity/user.go", "entity/user_friend.go", "model/user.go", "model/user_friend.go"},
+		},
+		{
+			name: "add_table",
+			change: func(t *testing.T, dir string) {
+				writeTestFile(t, sqlPath(dir, "presents.sql"), "CREATE TABLE presents (\n  id BIGINT(20) UNSIGNED NOT NULL,\n  PRIMARY KEY (id)\n);")
+			},
+			// structable.go follows the set of yaml files
+			written: []string{"dao/present.go", "entity/present.go", "entity/structable.go", "model/present.go", "schema/yaml/presents.yml"},
+		},
+		{
+			name: "drop_table",
+			change: func(t *testing.T, dir string) {
+				appendFile(t, sqlPath(dir, "items.sql"), "\nDROP TABLE items;")
+			},
+			written: []string{"entity/structable.go"},
+			removed: []string{"schema/yaml/items.yml"},
+			stale:   []string{"entity/item.go", "dao/item.go", "model/item.go"},
+		},
+		{
+			name:  "drop_table_prune",
+			prune: true,
+			change: func(t *testing.T, dir string) {
+				appendFile(t, sqlPath(dir, "items.sql"), "\nDROP TABLE items;")
+			},
+			written: []string{"entity/structable.go"},
+			removed: []string{"dao/item.go", "entity/item.go", "model/item.go", "schema/yaml/items.yml"},
+		},
+		{
+			name:  "rename_table_prune",
+			prune: true,
+			change: func(t *testing.T, dir string) {
+				appendFile(t, sqlPath(dir, "items.sql"), "\nRENAME TABLE items TO goods;")
+			},
+			written: []string{"dao/good.go", "entity/good.go", "entity/structable.go", "model/good.go", "schema/yaml/goods.yml"},
+			removed: []string{"dao/item.go", "entity/item.go", "model/item.go", "schema/yaml/items.yml"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "remodel")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+			for name, body := range ddl {
+				writeTestFile(t, sqlPath(dir, name), body)
+			}
+			cfg := NewConfig(dir)
+			cfg.Module = "example"
+			if err := (&Tables{}).Generate(cfg); err != nil {
+				t.Fatal(err)
+			}
+
+			cfg.Prune = tc.prune
+			w := newWatcher(cfg)
+			prev := mustSnapshot(t, w)
+			tc.change(t, dir)
+			rw := &recordWriter{root: dir}
+			cfg.Writer = rw
+			var logs bytes.Buffer
+			log.SetOutput(&logs)
+			next := w.poll(prev)
+			log.SetOutput(os.Stderr)
+
+			sort.Strings(rw.written)
+			assert.Equals(t, rw.written, tc.written)
+			sort.Strings(rw.removed)
+			assert.Equals(t, rw.removed, tc.removed)
+			for _, path := range tc.stale {
+				if !strings.Contains(logs.String(), "warning: "+path+" is stale") {
+					t.Errorf("%s is not logged as stale: %s", path, logs.String())
+				}
+			}
+			// files written by poll are not changes at the next tick
+			changed, removed := next.diff(mustSnapshot(t, w))
+			assert.Len(t, changed, 0)
+			assert.Len(t, removed, 0)
+		})
+	}
+}
+
+func mustSnapshot(t *testing.T, w *watcher) snapshot {
+	t.Helper()
+	s, err := w.snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}